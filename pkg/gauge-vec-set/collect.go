@@ -0,0 +1,70 @@
+package gauge_vec_set
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// frozenMetric is a prometheus.Metric whose value was written out at collection time.
+//
+// The children of a metric vector are live: the registry calls Write on them after Collect returns, so their values
+// may change in between. Freezing them under the group locks makes a scrape reflect a single state of every group.
+type frozenMetric struct {
+	desc   *prometheus.Desc
+	metric *dto.Metric
+}
+
+// Desc implements prometheus.Metric.
+func (m frozenMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+// Write implements prometheus.Metric.
+func (m frozenMetric) Write(out *dto.Metric) error {
+	out.Label = m.metric.Label
+	out.Gauge = m.metric.Gauge
+	out.Counter = m.metric.Counter
+	out.Summary = m.metric.Summary
+	out.Untyped = m.metric.Untyped
+	out.Histogram = m.metric.Histogram
+	out.TimestampMs = m.metric.TimestampMs
+	return nil
+}
+
+// freezeLocked collects the metric vector and freezes the value of every child.
+// The caller must hold every group lock for reading.
+func (c *VecSet[T]) freezeLocked() []prometheus.Metric {
+	live := make(chan prometheus.Metric, 64)
+	go func() {
+		c.metric.Collect(live)
+		close(live)
+	}()
+
+	var frozen []prometheus.Metric
+	for m := range live {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			frozen = append(frozen, prometheus.NewInvalidMetric(m.Desc(), err))
+			continue
+		}
+		frozen = append(frozen, frozenMetric{desc: m.Desc(), metric: pb})
+	}
+	return frozen
+}
+
+// constMetricsLocked freezes the value of every series in the nested index into a const metric, for sets whose
+// metric vector does not keep its children. The caller must hold every group lock for reading.
+func (c *VecSet[T]) constMetricsLocked() []prometheus.Metric {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	metrics := make([]prometheus.Metric, 0, c.series)
+	for _, groupMap := range c.indexes {
+		for _, group := range groupMap {
+			for _, s := range group {
+				metrics = append(metrics, c.owned.constMetric(s.metric, s.values))
+			}
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,85 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CounterVecSet wraps a Prometheus CounterVec with the index/group bookkeeping of VecSet,
+// so per-object counters can be removed in bulk with DeleteByIndex, DeleteByIndexPrefix and DeleteByGroup.
+type CounterVecSet struct {
+	VecSet[prometheus.Counter]
+}
+
+// NewCounterVecSet constructs a CounterVecSet. The parameters have the same meaning as for NewGaugeVecSet.
+//
+// Returns an *unregistered* collector; register it with a Prometheus registry yourself.
+func NewCounterVecSet(
+	namespace, subsystem, name, help string,
+	indexLabels []string,
+	groupLabels []string,
+	extraLabels ...string,
+) *CounterVecSet {
+	validateLowercaseUnderscore(namespace)
+	validateLowercaseUnderscore(subsystem)
+	validateLowercaseUnderscore(name)
+
+	allLabels := validateLabels(indexLabels, groupLabels, extraLabels)
+
+	cv := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, allLabels)
+	discarded := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	})
+
+	return &CounterVecSet{
+		VecSet: newVecSet[prometheus.Counter](
+			prometheus.BuildFQName(namespace, subsystem, name), cv, discarded, indexLabels, groupLabels, extraLabels,
+		),
+	}
+}
+
+// Inc increments the counter identified by (index, group, extra) by 1.
+func (c *CounterVecSet) Inc(indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TryInc(indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TryInc is like Inc but returns an error instead of invoking the error handler.
+func (c *CounterVecSet) TryInc(indexValues []string, groupValues []string, extraValues ...string) error {
+	counter, err := c.TryWithLabelValues(indexValues, groupValues, extraValues...)
+	if err != nil {
+		return err
+	}
+	counter.Inc()
+	return nil
+}
+
+// Add adds value to the counter identified by (index, group, extra). value must not be negative.
+func (c *CounterVecSet) Add(value float64, indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TryAdd(value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TryAdd is like Add but returns an error instead of invoking the error handler.
+func (c *CounterVecSet) TryAdd(value float64, indexValues []string, groupValues []string, extraValues ...string) error {
+	if value < 0 {
+		return fmt.Errorf("%w: got %v", ErrNegativeCounterIncrement, value)
+	}
+	counter, err := c.TryWithLabelValues(indexValues, groupValues, extraValues...)
+	if err != nil {
+		return err
+	}
+	counter.Add(value)
+	return nil
+}
@@ -0,0 +1,128 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+	"slices"
+)
+
+// enumLabel restricts the values of one extra label to a declared, ordered set of states.
+type enumLabel struct {
+	label    string
+	position int // position of label within extraLabels
+	states   []string
+	allowed  map[string]struct{}
+	// stateSet enforces the OpenMetrics StateSet conventions (values 0 or 1, label named after the metric).
+	stateSet bool
+}
+
+// validate ensures the enum label's value in extraValues is a declared state.
+func (e *enumLabel) validate(extraValues []string) error {
+	if _, ok := e.allowed[extraValues[e.position]]; !ok {
+		return fmt.Errorf("%w: %q is not one of %v for label %q",
+			ErrUnknownState, extraValues[e.position], e.states, e.label)
+	}
+	return nil
+}
+
+// WithEnum declares the allowed states of the extra label extraLabel and returns the set for chaining.
+//
+// Once declared:
+//   - every operation rejects values of extraLabel that are not one of states (ErrUnknownState).
+//   - SetActiveInGroup materializes all other states at 0, so the full enum is visible from the first write.
+//
+// WithEnum panics if extraLabel is not an extra label, if an enum was already declared or if states is empty or
+// contains duplicates. Call it right after construction, before the set is used.
+//
+// Example:
+//
+//	col := NewGaugeVecSet(ns, sub, name, help, []string{"namespace"}, []string{"pod"}, "phase").
+//		WithEnum("phase", "Pending", "Running", "Succeeded", "Failed")
+func (c *GaugeVecSet) WithEnum(extraLabel string, states ...string) *GaugeVecSet {
+	c.declareEnum(extraLabel, states...)
+	return c
+}
+
+// declareEnum implements WithEnum.
+func (c *HierarchicalGaugeSet) declareEnum(extraLabel string, states ...string) {
+	if c.enum != nil {
+		panic(fmt.Sprintf("GaugeVecSet: enum already declared for label %q", c.enum.label))
+	}
+	position := slices.Index(c.extraLabels, extraLabel)
+	if position < 0 {
+		panic(fmt.Sprintf("GaugeVecSet: enum label %q is not one of the extra labels %v", extraLabel, c.extraLabels))
+	}
+	if len(states) == 0 {
+		panic(fmt.Sprintf("GaugeVecSet: enum label %q requires at least one state", extraLabel))
+	}
+
+	allowed := make(map[string]struct{}, len(states))
+	for _, state := range states {
+		if _, exists := allowed[state]; exists {
+			panic(fmt.Sprintf("GaugeVecSet: duplicate state %q for enum label %q", state, extraLabel))
+		}
+		allowed[state] = struct{}{}
+	}
+
+	c.enum = &enumLabel{
+		label:    extraLabel,
+		position: position,
+		states:   append([]string(nil), states...),
+		allowed:  allowed,
+	}
+}
+
+// AsStateSet makes the declared enum follow the OpenMetrics StateSet conventions and returns the set for chaining:
+//   - the enum label must be named after the metric (e.g. label "kube_pod_status_phase" for that metric).
+//   - series only take the values 0 and 1; other values are rejected with ErrStateSetValue, and so are the
+//     relative operations (Add, Sub, Inc, Dec, ...).
+//
+// Together with SetActiveInGroup this exposes every declared state with exactly one of them set to 1.
+// The series are still exported with the gauge type, since client_golang has no StateSet metric type.
+//
+// AsStateSet panics if no enum was declared with WithEnum or if the enum label isn't named after the metric.
+func (c *GaugeVecSet) AsStateSet() *GaugeVecSet {
+	c.declareStateSet()
+	return c
+}
+
+// declareStateSet implements AsStateSet.
+func (c *HierarchicalGaugeSet) declareStateSet() {
+	if c.enum == nil {
+		panic("GaugeVecSet: AsStateSet requires an enum declared with WithEnum")
+	}
+	if c.enum.label != c.fqName {
+		panic(fmt.Sprintf("GaugeVecSet: state set label %q must be named after the metric %q", c.enum.label, c.fqName))
+	}
+	c.enum.stateSet = true
+}
+
+// validateStateValue rejects values a StateSet series cannot take.
+func (c *HierarchicalGaugeSet) validateStateValue(value float64) error {
+	if c.enum != nil && c.enum.stateSet && value != 0 && value != 1 {
+		return fmt.Errorf("%w: got %v", ErrStateSetValue, value)
+	}
+	return nil
+}
+
+// materializeStates sets every declared state other than the one in allValues to 0,
+// keeping the remaining label values, and caches the series under (indexKey, groupKey).
+// States the limits do not admit are skipped; they never evict other series (see admitWithoutEviction).
+func (c *HierarchicalGaugeSet) materializeStates(indexKey, groupKey string, allValues []string) {
+	position := len(c.indexLabels) + len(c.groupLabels) + c.enum.position
+	active := allValues[position]
+
+	for _, state := range c.enum.states {
+		if state == active {
+			continue
+		}
+		values := append([]string(nil), allValues...)
+		values[position] = state
+		ref, ok := c.admitWithoutEviction(indexKey, groupKey, values)
+		if !ok || ref.folded {
+			continue
+		}
+		g := c.child(ref)
+		g.Set(0)
+		c.cacheWithKeys(ref, g)
+	}
+}
@@ -0,0 +1,100 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure activating one declared state materializes all other states at 0 from the first write
+func Test_DynamicGaugeCollector_Enum_MaterializesStates(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewGaugeVecSet(
+		"kube",
+		"pod_status",
+		"phase",
+		"help text",
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		"phase", "reason",     // extra
+	).WithEnum("phase", "Pending", "Running", "Failed")
+	require.NoError(t, reg.Register(col))
+
+	col.SetActiveInGroup(1, []string{"prod"}, []string{"nginx"}, "Pending", "")
+
+	want := `
+# HELP kube_pod_status_phase help text
+# TYPE kube_pod_status_phase gauge
+kube_pod_status_phase{namespace="prod",phase="Failed",pod="nginx",reason=""} 0
+kube_pod_status_phase{namespace="prod",phase="Pending",pod="nginx",reason=""} 1
+kube_pod_status_phase{namespace="prod",phase="Running",pod="nginx",reason=""} 0
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "kube_pod_status_phase"))
+
+	col.SetActiveInGroup(1, []string{"prod"}, []string{"nginx"}, "Running", "")
+
+	want = `
+# HELP kube_pod_status_phase help text
+# TYPE kube_pod_status_phase gauge
+kube_pod_status_phase{namespace="prod",phase="Failed",pod="nginx",reason=""} 0
+kube_pod_status_phase{namespace="prod",phase="Pending",pod="nginx",reason=""} 0
+kube_pod_status_phase{namespace="prod",phase="Running",pod="nginx",reason=""} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "kube_pod_status_phase"))
+
+	// Unknown states are rejected by every operation
+	assert.ErrorIs(t, col.TrySetActiveInGroup(1, []string{"prod"}, []string{"nginx"}, "Exploded", ""), ErrUnknownState)
+	assert.ErrorIs(t, col.TrySet(1, []string{"prod"}, []string{"nginx"}, "Exploded", ""), ErrUnknownState)
+	assert.ErrorIs(t, col.TryInc([]string{"prod"}, []string{"nginx"}, "Exploded", ""), ErrUnknownState)
+	assert.Panics(t, func() { col.BindGroup([]string{"prod"}, "nginx").SetGroup(1, "Exploded", "") })
+
+	assert.Equal(t, 3, col.DeleteByGroup([]string{"prod"}, "nginx"))
+}
+
+func Test_DynamicGaugeCollector_Enum_DeclarationPanics(t *testing.T) {
+	newCol := func() *GaugeVecSet {
+		return NewGaugeVecSet("testns", "subsys", "enum", "help text", []string{"index"}, []string{"group"}, "state")
+	}
+
+	assert.Panics(t, func() { newCol().WithEnum("group", "a") }, "not an extra label")
+	assert.Panics(t, func() { newCol().WithEnum("state") }, "no states")
+	assert.Panics(t, func() { newCol().WithEnum("state", "a", "a") }, "duplicate state")
+	assert.Panics(t, func() { newCol().WithEnum("state", "a").WithEnum("state", "b") }, "declared twice")
+	assert.Panics(t, func() { newCol().AsStateSet() }, "state set without enum")
+	assert.Panics(t, func() { newCol().WithEnum("state", "a").AsStateSet() }, "label not named after metric")
+}
+
+// Ensure the StateSet mode only accepts boolean values
+func Test_DynamicGaugeCollector_Enum_StateSet(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"phase",
+		"help text",
+		[]string{"name"},      // index
+		[]string{"kind"},      // group
+		"testns_subsys_phase", // extra, named after the metric
+	).WithEnum("testns_subsys_phase", "Pending", "Running").AsStateSet()
+	require.NoError(t, reg.Register(col))
+
+	col.SetActiveInGroup(1, []string{"a"}, []string{"Pod"}, "Running")
+
+	want := `
+# HELP testns_subsys_phase help text
+# TYPE testns_subsys_phase gauge
+testns_subsys_phase{kind="Pod",name="a",testns_subsys_phase="Pending"} 0
+testns_subsys_phase{kind="Pod",name="a",testns_subsys_phase="Running"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_phase"))
+
+	assert.ErrorIs(t, col.TrySetActiveInGroup(2, []string{"a"}, []string{"Pod"}, "Running"), ErrStateSetValue)
+	assert.ErrorIs(t, col.TryAdd(1, []string{"a"}, []string{"Pod"}, "Running"), ErrStateSetValue)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_phase"))
+}
@@ -0,0 +1,52 @@
+package gauge_vec_set
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrIndexArity is returned when the number of index values does not match the configured index labels.
+	ErrIndexArity = errors.New("index values arity mismatch")
+	// ErrGroupArity is returned when the number of group values does not match the configured group labels.
+	ErrGroupArity = errors.New("group values arity mismatch")
+	// ErrExtraArity is returned when the number of extra values does not match the configured extra labels.
+	ErrExtraArity = errors.New("extra values arity mismatch")
+	// ErrLevelArity is returned when the number of values does not match the labels of the levels of an operation.
+	ErrLevelArity = errors.New("level values arity mismatch")
+	// ErrUnknownLevel is returned when a level is not one of the levels of a HierarchicalGaugeSet, or is the group
+	// level of a GaugeVecSet without group labels.
+	ErrUnknownLevel = errors.New("unknown level")
+	// ErrMissingLabel is returned when a label map lacks one of the labels required by the operation.
+	ErrMissingLabel = errors.New("missing label")
+	// ErrUnknownLabel is returned when a label map contains a label not accepted by the operation.
+	ErrUnknownLabel = errors.New("unknown label")
+	// ErrNegativeCounterIncrement is returned when a negative value is added to a counter.
+	ErrNegativeCounterIncrement = errors.New("counter cannot decrease in value")
+	// ErrUnknownState is returned when the value of an enum label is not one of its declared states.
+	ErrUnknownState = errors.New("unknown enum state")
+	// ErrStateSetValue is returned when a StateSet series would be set to a value other than 0 or 1.
+	ErrStateSetValue = errors.New("state set values must be 0 or 1")
+	// ErrCardinalityLimit is returned when a write would create a series beyond a limit with OverflowReject.
+	ErrCardinalityLimit = errors.New("cardinality limit reached")
+	// ErrSnapshotVersion is returned when a snapshot was written in a format version Restore does not support.
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+	// ErrSnapshotSchema is returned when the labels of a snapshot differ from the labels of the set restoring it.
+	ErrSnapshotSchema = errors.New("snapshot label schema mismatch")
+)
+
+// ErrorHandler receives errors raised by the non-Try operations of a GaugeVecSet.
+//
+// The default handler panics with the error. Install a different handler (e.g. one that logs) with
+// GaugeVecSet.SetErrorHandler if a single malformed label tuple must not bring down the process.
+type ErrorHandler func(err error)
+
+// PanicErrorHandler is the default ErrorHandler. It panics with the given error.
+func PanicErrorHandler(err error) {
+	panic(err)
+}
+
+// arityError wraps one of the arity sentinels with the expected labels and the received count.
+func arityError(sentinel error, kind string, labels []string, got int) error {
+	return fmt.Errorf("%w: expected %d %sValues for labels %v, got %d", sentinel, len(labels), kind, labels, got)
+}
@@ -1,25 +1,17 @@
 package gauge_vec_set
 
 import (
-	"fmt"
-	"regexp"
-	"strings"
-	"sync"
-
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-const (
-	// We hash all label values into a single string separated by this character
-	labelHashSeparatorChar = "`"
-	// If the label values contain the labelHashSeparator, replace it with this value
-	labelHashCollisionReplacementChar = ""
-)
-
-// GaugeVecSet wraps a Prometheus GaugeVec and keeps a 3-level index:
+// GaugeVecSet wraps a Prometheus GaugeVec and keeps a 3-level index (see VecSet):
 //
 //	indexKey -> groupKey -> set(fullKey)
 //
+// It is the HierarchicalGaugeSet of the three levels index, group and extra, so DeleteAt and SetExclusiveAt take
+// level 0 for the index, 1 for the group and 2 for a single series. Without group labels, level 1 is rejected with
+// ErrUnknownLevel.
+//
 // Label order in the metric is:
 //
 //	allLabels = indexLabels + groupLabels + extraLabels
@@ -40,38 +32,11 @@ const (
 //
 //	This collector maintains an in-memory index of *every* exported series, keyed by index/group.
 //	If the set of index values grows without bound, memory usage will grow accordingly. Prefer bounded
-//	index/group label spaces and avoid high-cardinality values.
+//	index/group label spaces and avoid high-cardinality values, or cap the number of series with WithLimits.
+//	WithStandaloneStorage keeps the values in the index itself rather than in a GaugeVec, so series aren't
+//	stored twice.
 type GaugeVecSet struct {
-	metric *prometheus.GaugeVec
-
-	indexLabels []string // labels that define the deletion index (required; order matters)
-	groupLabels []string // labels that define a mutually-exclusive group (optional; order matters)
-	extraLabels []string // additional dynamic labels not used for grouping (optional; order matters)
-
-	// Nested index: indexKey -> groupKey -> set(fullKey)
-	indexes map[string]map[string]map[string]struct{}
-
-	mu sync.RWMutex
-}
-
-// validateLowercaseUnderscore panics if the input contains any character
-// that is not a lowercase letter, number or underscore.
-func validateLowercaseUnderscore(input string) {
-	invalidCharPattern := regexp.MustCompile(`[^a-z0-9_]`)
-	if invalidCharPattern.MatchString(input) {
-		panic(
-			fmt.Errorf(
-				"NewGaugeVecSet: %q contains characters other than lowercase letters and underscores", input,
-			),
-		)
-	}
-	if strings.HasSuffix(input, "_") {
-		panic(
-			fmt.Errorf(
-				"NewGaugeVecSet: %q must not end with an underscore", input,
-			),
-		)
-	}
+	HierarchicalGaugeSet
 }
 
 // NewGaugeVecSet constructs a GaugeVecSet.
@@ -95,214 +60,37 @@ func NewGaugeVecSet(
 	groupLabels []string,
 	extraLabels ...string,
 ) *GaugeVecSet {
-	validateLowercaseUnderscore(namespace)
-	validateLowercaseUnderscore(subsystem)
-	validateLowercaseUnderscore(name)
-
-	if len(indexLabels) == 0 {
-		panic("NewMultiIndexGaugeCollector: at least one index label is required")
-	}
-	allLabels := buildAllValues(indexLabels, groupLabels, extraLabels)
-
-	// Validate that all labels are unique
-	seen := make(map[string]struct{}, len(allLabels))
-	for _, label := range allLabels {
-		if _, exists := seen[label]; exists {
-			panic(
-				fmt.Sprintf(
-					"GaugeVecSet: duplicate label %q detected across index/group/extra labels", label),
-			)
-		}
-		seen[label] = struct{}{}
-	}
-
-	gv := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	return NewGaugeVecSetWithOpts(prometheus.GaugeOpts{
 		Namespace: namespace,
 		Subsystem: subsystem,
 		Name:      name,
 		Help:      help,
-	}, allLabels)
-
-	return &GaugeVecSet{
-		metric:      gv,
-		indexLabels: indexLabels,
-		groupLabels: groupLabels,
-		extraLabels: extraLabels,
-		indexes:     make(map[string]map[string]map[string]struct{}),
-	}
-}
-
-// Describe implements prometheus.Collector.
-func (c *GaugeVecSet) Describe(ch chan<- *prometheus.Desc) {
-	c.metric.Describe(ch)
-}
-
-// Collect implements prometheus.Collector.
-func (c *GaugeVecSet) Collect(ch chan<- prometheus.Metric) {
-	c.metric.Collect(ch)
-}
-
-// containLabelHashSeparator returns true if any of the strings in the given array contains the labelHashSeparatorChar
-// In most cases we're not going to encounter labelHashSeparatorChar in the label values.
-// So we prevent a new array allocation by checking if the character is present.
-func containLabelHashSeparator(values []string) bool {
-	for _, v := range values {
-		if strings.Contains(v, labelHashSeparatorChar) {
-			return true
-		}
-	}
-	return false
-}
-
-// removeLabelHashSeparator returns a new slice with any labelHashSeparatorChar replaced by labelHashCollisionReplacementChar
-func removeLabelHashSeparator(values []string) []string {
-	clean := make([]string, len(values))
-	for i, v := range values {
-		clean[i] = strings.ReplaceAll(v, labelHashSeparatorChar, labelHashCollisionReplacementChar)
-	}
-	return clean
+	}, indexLabels, groupLabels, extraLabels)
 }
 
-// buildAllValues concatenates values in the canonical order: index + group + extra.
-func buildAllValues(indexValues, groupValues, extraValues []string) []string {
-	allVals := make([]string, 0, len(indexValues)+len(groupValues)+len(extraValues))
-	allVals = append(allVals, indexValues...)
-	allVals = append(allVals, groupValues...)
-	allVals = append(allVals, extraValues...)
-
-	if !containLabelHashSeparator(allVals) {
-		return allVals
-	}
-	return removeLabelHashSeparator(allVals)
-}
-
-// serialize joins label values with the separator labelHashSeparatorChar.
-func serialize(labelValues []string) string {
-	if !containLabelHashSeparator(labelValues) {
-		return strings.Join(labelValues, labelHashSeparatorChar)
-	}
-
-	return strings.Join(removeLabelHashSeparator(labelValues), labelHashSeparatorChar)
-}
-
-// deserialize the hash into label values
-func deserialize(s string) []string {
-	return strings.Split(s, labelHashSeparatorChar)
-}
-
-// listHashesForIndex returns a flat slice of all hashes under indexKey.
-// Safe for concurrent use, holds RLock briefly.
-func (c *GaugeVecSet) listHashesForIndex(indexKey string) []string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	groupMap, ok := c.indexes[indexKey]
-	if !ok {
-		return nil
-	}
-
-	var hashes []string
-	for _, group := range groupMap {
-		for hash := range group {
-			hashes = append(hashes, hash)
-		}
-	}
-
-	return hashes
-}
-
-// listHashesForGroup returns all hashes under (indexKey, groupKey).
-// Safe for concurrent use, holds RLock briefly.
-func (c *GaugeVecSet) listHashesForGroup(indexKey, groupKey string) []string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	groupMap, ok := c.indexes[indexKey]
-	if !ok {
-		return nil
-	}
-	group, ok := groupMap[groupKey]
-	if !ok {
-		return nil
-	}
-	hashes := make([]string, 0, len(group))
-	for hash := range group {
-		hashes = append(hashes, hash)
-	}
-	return hashes
-}
-
-// validateIndexValues ensures the arity of indexValues matches the configured indexLabels.
-func (c *GaugeVecSet) validateIndexValues(indexValues []string) {
-	if len(indexValues) != len(c.indexLabels) {
-		panic(fmt.Sprintf("expected %d indexValues for labels %v, got %d",
-			len(c.indexLabels), c.indexLabels, len(indexValues)))
-	}
-}
-
-// validateGroupValues ensures the arity of groupValues matches the configured groupLabels.
-func (c *GaugeVecSet) validateGroupValues(groupValues []string) {
-	if len(groupValues) != len(c.groupLabels) {
-		panic(
-			fmt.Sprintf("expected %d groupValues for labels %v, got %d",
-				len(c.groupLabels), c.groupLabels, len(groupValues)))
-	}
-}
-
-// validateExtraValues ensures the arity of extraValues matches the configured extraLabels.
-func (c *GaugeVecSet) validateExtraValues(extraValues []string) {
-	if len(extraValues) != len(c.extraLabels) {
-		panic(fmt.Sprintf("expected %d extraValues for labels %v, got %d",
-			len(c.extraLabels), c.extraLabels, len(extraValues)))
-	}
-}
-
-// pruneIndex removes the entire indexKey bucket from the cache.
-// Holds a write lock momentarily while removing the index.
-func (c *GaugeVecSet) pruneIndex(indexKey string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	delete(c.indexes, indexKey)
-}
-
-// pruneGroup removes the (indexKey, groupKey) bucket from the cache and prunes the index if empty.
-// Holds a write lock momentarily while removing the group.
-func (c *GaugeVecSet) pruneGroup(indexKey, groupKey string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if groupMap, ok := c.indexes[indexKey]; ok {
-		delete(groupMap, groupKey)
-		if len(groupMap) == 0 {
-			delete(c.indexes, indexKey)
-		}
-	}
-}
-
-// cache records the full label tuple under (indexKey, groupKey).
-func (c *GaugeVecSet) cache(indexValues, groupValues, allValues []string) {
-	indexKey := serialize(indexValues)
-	groupKey := serialize(groupValues)
-	fullKey := serialize(allValues)
-	c.cacheWithKeys(indexKey, groupKey, fullKey)
-}
-
-// cacheWithKeys records a fullKey under the nested (indexKey, groupKey) maps.
-func (c *GaugeVecSet) cacheWithKeys(indexKey, groupKey, fullKey string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	indexSet, ok := c.indexes[indexKey]
-	if !ok {
-		indexSet = make(map[string]map[string]struct{})
-		c.indexes[indexKey] = indexSet
-	}
-	groupSet, ok := indexSet[groupKey]
-	if !ok {
-		groupSet = make(map[string]struct{})
-		indexSet[groupKey] = groupSet
-	}
-
-	groupSet[fullKey] = struct{}{}
+// NewGaugeVecSetWithOpts constructs a GaugeVecSet from the full prometheus.GaugeOpts (e.g. to attach ConstLabels)
+// and applies the given set-specific options.
+//
+// The label parameters have the same meaning as for NewGaugeVecSet. Const labels must not share a name with any
+// of the index, group or extra labels.
+//
+// Example:
+//
+//	col := NewGaugeVecSetWithOpts(
+//		prometheus.GaugeOpts{Namespace: ns, Name: name, Help: help, ConstLabels: prometheus.Labels{"cluster": "eu1"}},
+//		[]string{"namespace"}, []string{"pod"}, []string{"phase"},
+//		WithEnum("phase", "Pending", "Running"),
+//	)
+func NewGaugeVecSetWithOpts(
+	opts prometheus.GaugeOpts,
+	indexLabels []string,
+	groupLabels []string,
+	extraLabels []string,
+	options ...Option,
+) *GaugeVecSet {
+	c := &GaugeVecSet{}
+	c.build(opts, [][]string{indexLabels, groupLabels, extraLabels}, 1, options)
+	return c
 }
 
 // Set assigns the Gauge value for the series identified by (index, group)
@@ -313,13 +101,26 @@ func (c *GaugeVecSet) Set(
 	groupValues []string,
 	extraValues ...string,
 ) {
-	c.validateIndexValues(indexValues)
-	c.validateGroupValues(groupValues)
-	c.validateExtraValues(extraValues)
+	if err := c.TrySet(value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySet is like Set but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TrySet(
+	value float64,
+	indexValues []string,
+	groupValues []string,
+	extraValues ...string,
+) error {
+	if err := c.validateValues(indexValues, groupValues, extraValues); err != nil {
+		return err
+	}
+	if err := c.validateStateValue(value); err != nil {
+		return err
+	}
 
-	allVals := buildAllValues(indexValues, groupValues, extraValues)
-	c.metric.WithLabelValues(allVals...).Set(value)
-	c.cache(indexValues, groupValues, allVals)
+	return c.set(value, serialize(indexValues), serialize(groupValues), buildAllValues(indexValues, groupValues, extraValues))
 }
 
 // SetActiveInGroup sets the target series to `value` and zeroes **all other series**
@@ -333,31 +134,28 @@ func (c *GaugeVecSet) SetActiveInGroup(
 	groupValues []string,
 	extraValues ...string,
 ) {
-	if len(c.groupLabels) == 0 {
-		c.Set(value, indexValues, groupValues, extraValues...)
-		return
+	if err := c.TrySetActiveInGroup(value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
 	}
-	c.validateIndexValues(indexValues)
-	c.validateGroupValues(groupValues)
-	c.validateExtraValues(extraValues)
-
-	allValues := buildAllValues(indexValues, groupValues, extraValues)
-	fullKey := serialize(allValues)
-	indexKey := serialize(indexValues)
-	groupKey := serialize(groupValues)
+}
 
-	// Snapshot hashes (no locks held during Prometheus calls).
-	hashes := c.listHashesForGroup(indexKey, groupKey)
-	for _, hash := range hashes {
-		if hash == fullKey {
-			continue
-		}
-		c.metric.WithLabelValues(deserialize(hash)...).Set(0)
+// TrySetActiveInGroup is like SetActiveInGroup but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TrySetActiveInGroup(
+	value float64,
+	indexValues []string,
+	groupValues []string,
+	extraValues ...string,
+) error {
+	if err := c.validateValues(indexValues, groupValues, extraValues); err != nil {
+		return err
+	}
+	if err := c.validateStateValue(value); err != nil {
+		return err
 	}
 
-	// Set target and cache.
-	c.metric.WithLabelValues(allValues...).Set(value)
-	c.cacheWithKeys(indexKey, groupKey, fullKey)
+	return c.setActiveInGroup(
+		value, serialize(indexValues), serialize(groupValues), buildAllValues(indexValues, groupValues, extraValues),
+	)
 }
 
 // SetGroup deletes all other series for (index, group) and then sets the given one to the passed in value.
@@ -369,48 +167,49 @@ func (c *GaugeVecSet) SetActiveInGroup(
 func (c *GaugeVecSet) SetGroup(
 	value float64, indexValues []string, groupValues []string, extraValues ...string,
 ) {
-	_ = c.DeleteByGroup(indexValues, groupValues...)
-	c.Set(value, indexValues, groupValues, extraValues...)
+	if err := c.TrySetGroup(value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
 }
 
-// DeleteByIndex removes all series whose index label-values tuple equals indexValues.
-// Returns the number of deleted series.
-func (c *GaugeVecSet) DeleteByIndex(indexValues ...string) (deleted int) {
-	c.validateIndexValues(indexValues)
-
-	indexKey := serialize(indexValues)
-	hashes := c.listHashesForIndex(indexKey)
-
-	for _, hash := range hashes {
-		if c.metric.DeleteLabelValues(deserialize(hash)...) {
-			deleted++
-		}
+// TrySetGroup is like SetGroup but returns an error instead of invoking the error handler.
+// Arity is validated before any series is deleted.
+func (c *GaugeVecSet) TrySetGroup(
+	value float64, indexValues []string, groupValues []string, extraValues ...string,
+) error {
+	if err := c.validateValues(indexValues, groupValues, extraValues); err != nil {
+		return err
 	}
-	c.pruneIndex(indexKey)
-
-	return deleted
+	if err := c.validateStateValue(value); err != nil {
+		return err
+	}
+	return c.setGroup(value, serialize(indexValues), serialize(groupValues), buildAllValues(indexValues, groupValues, extraValues))
 }
 
-// DeleteByGroup removes all series for the given (indexValues, groupValues) pair.
-// Returns the number of deleted series.
-func (c *GaugeVecSet) DeleteByGroup(indexValues []string, groupValues ...string) (deleted int) {
+// setGroup deletes all series in (indexKey, groupKey) and then sets the series identified by allValues.
+//
+// The transition holds the group's lock, so Collect never observes the group without any series, and concurrent
+// exclusive writes on the same group are linearizable: exactly one series survives.
+func (c *GaugeVecSet) setGroup(value float64, indexKey, groupKey string, allValues []string) error {
 	if len(c.groupLabels) == 0 {
-		return 0
-	}
-	c.validateIndexValues(indexValues)
-	c.validateGroupValues(groupValues)
-
-	indexKey := serialize(indexValues)
-	groupKey := serialize(groupValues)
-	hashes := c.listHashesForGroup(indexKey, groupKey)
-
-	for _, hash := range hashes {
-		if c.metric.DeleteLabelValues(deserialize(hash)...) {
-			deleted++
-		}
+		return c.set(value, indexKey, groupKey, allValues)
 	}
 
-	c.pruneGroup(indexKey, groupKey)
+	groupLock := c.groupLocks.stripe(indexKey, groupKey)
+	groupLock.Lock()
+	defer groupLock.Unlock()
 
-	return deleted
+	c.mu.Lock()
+	detached := c.detachGroupLocked(indexKey, groupKey)
+	ref, err := c.admitLocked(indexKey, groupKey, allValues)
+	c.mu.Unlock()
+
+	c.deleteSeries(detached)
+	if err != nil {
+		return err
+	}
+	g := c.child(ref)
+	g.Set(value)
+	c.cacheWithKeys(ref, g)
+	return nil
 }
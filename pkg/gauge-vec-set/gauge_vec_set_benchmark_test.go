@@ -3,7 +3,10 @@ package gauge_vec_set
 import (
 	"fmt"
 	"math/rand"
+	"runtime"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 /*
@@ -348,3 +351,300 @@ func Benchmark_DynamicGaugeCollector_DeleteByIndex(b *testing.B) {
 		}
 	}
 }
+
+// DeleteByIndexPrefix: populate `indexes` indexes sharing the first index value (plus as many unrelated ones),
+// then time deleting by that one-value prefix. Populate once, then loop: delete (timed) -> repopulate (untimed).
+func Benchmark_DynamicGaugeCollector_DeleteByIndexPrefix(b *testing.B) {
+	const idxN, grpN, extN = 3, 1, 1
+	indexCounts := []int{10, 100, 1000}
+
+	for _, indexes := range indexCounts {
+		name := fmt.Sprintf("idx=%d_grp=%d_ext=%d/indexes=%d", idxN, grpN, extN, indexes)
+		b.Run(name, func(b *testing.B) {
+			col := newParamCollector("bench_del_index_prefix", idxN, grpN, extN)
+			populate := func(first string) {
+				for i := 0; i < indexes; i++ {
+					idxVals := []string{first, fmt.Sprintf("ns_%d", i%10), fmt.Sprintf("name_%d", i)}
+					col.Set(1, idxVals, makeGroupValues(i, grpN), makeExtraValues(i, extN)...)
+				}
+			}
+
+			// Initial population (not timed).
+			populate("target")
+			populate("other")
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			// Report contextual metrics.
+			b.ReportMetric(float64(indexes), "series/op")
+
+			for i := 0; i < b.N; i++ {
+				_ = col.DeleteByIndexPrefix("target")
+				b.StopTimer()
+				// Repopulate same set (not timed).
+				populate("target")
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+// SetActiveInGroup through a GroupHandle, which skips validating and serializing the index and group values.
+func Benchmark_DynamicGaugeCollector_GroupHandle_SetActiveInGroup(b *testing.B) {
+	var tuples [][3]int
+	var sibs []int
+	tuples, sibs = labelVariations, siblings
+
+	for _, t := range tuples {
+		idxN, grpN, extN := t[0], t[1], t[2]
+		L := labelsCount(idxN, grpN, extN)
+		for _, sib := range sibs {
+			name := fmt.Sprintf("idx=%d_grp=%d_ext=%d/siblings=%d", idxN, grpN, extN, sib)
+			b.Run(name, func(b *testing.B) {
+				col := newParamCollector("bench_handle_set_excl", idxN, grpN, extN)
+				handle := col.BindGroup(makeIndexValues(0, idxN), makeGroupValues(0, grpN)...)
+
+				// Pre-create siblings (not timed).
+				for j := 0; j < sib; j++ {
+					handle.Set(0, makeExtraValues(j, extN)...)
+				}
+
+				r := rand.New(rand.NewSource(1337))
+				b.ReportAllocs()
+				b.ResetTimer()
+				// Report contextual metrics.
+				b.ReportMetric(float64(sib), "series/op")
+				b.ReportMetric(float64(L), "labels/op")
+
+				for i := 0; i < b.N; i++ {
+					j := r.Intn(max(1, sib))
+					handle.SetActiveInGroup(1, makeExtraValues(j, extN)...)
+				}
+			})
+		}
+	}
+}
+
+// Sibling-heavy groups, where SetActiveInGroup and group reads are dominated by the per-sibling cost.
+var largeSiblings = []int{32, 128, 512}
+
+// SetActiveInGroup on large groups: every op zeroes all siblings through their cached child gauges.
+func Benchmark_DynamicGaugeCollector_SetActiveInGroup_LargeGroup(b *testing.B) {
+	for _, t := range [][3]int{{1, 1, 1}, {4, 4, 4}} {
+		idxN, grpN, extN := t[0], t[1], t[2]
+		L := labelsCount(idxN, grpN, extN)
+		for _, sib := range largeSiblings {
+			name := fmt.Sprintf("idx=%d_grp=%d_ext=%d/siblings=%d", idxN, grpN, extN, sib)
+			b.Run(name, func(b *testing.B) {
+				col := newParamCollector("bench_set_excl_large", idxN, grpN, extN)
+				idxVals := makeIndexValues(0, idxN)
+				grpVals := makeGroupValues(0, grpN)
+				extVals := make([][]string, sib)
+				for j := range extVals {
+					extVals[j] = []string{fmt.Sprintf("sibling_%d", j)}
+					extVals[j] = append(extVals[j], makeExtraValues(j, extN-1)...)
+					col.Set(0, idxVals, grpVals, extVals[j]...)
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				// Report contextual metrics.
+				b.ReportMetric(float64(sib), "series/op")
+				b.ReportMetric(float64(L), "labels/op")
+
+				for i := 0; i < b.N; i++ {
+					col.SetActiveInGroup(1, idxVals, grpVals, extVals[i%sib]...)
+				}
+			})
+		}
+	}
+}
+
+// GroupValues on large groups: every op reads all series of the group.
+func Benchmark_DynamicGaugeCollector_GroupValues_LargeGroup(b *testing.B) {
+	for _, t := range [][3]int{{1, 1, 1}, {4, 4, 4}} {
+		idxN, grpN, extN := t[0], t[1], t[2]
+		L := labelsCount(idxN, grpN, extN)
+		for _, sib := range largeSiblings {
+			name := fmt.Sprintf("idx=%d_grp=%d_ext=%d/siblings=%d", idxN, grpN, extN, sib)
+			b.Run(name, func(b *testing.B) {
+				col := newParamCollector("bench_group_values_large", idxN, grpN, extN)
+				idxVals := makeIndexValues(0, idxN)
+				grpVals := makeGroupValues(0, grpN)
+				for j := 0; j < sib; j++ {
+					extVals := append([]string{fmt.Sprintf("sibling_%d", j)}, makeExtraValues(j, extN-1)...)
+					col.Set(float64(j), idxVals, grpVals, extVals...)
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				// Report contextual metrics.
+				b.ReportMetric(float64(sib), "series/op")
+				b.ReportMetric(float64(L), "labels/op")
+
+				for i := 0; i < b.N; i++ {
+					_ = col.GroupValues(idxVals, grpVals...)
+				}
+			})
+		}
+	}
+}
+
+// Storage backends compared on the same series.
+var (
+	storageBackends = []struct {
+		name    string
+		options []Option
+	}{
+		{"gauge_vec", nil},
+		{"standalone", []Option{WithStandaloneStorage()}},
+	}
+	storageSeries = []int{100, 1000, 10000}
+)
+
+// newStorageCollector returns a 2/1/1 set holding n series with the given options.
+// Like values decoded from separate objects, repeated label values are distinct copies.
+func newStorageCollector(ns string, n int, options ...Option) *GaugeVecSet {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: ns, Subsystem: "sub", Name: "dg_storage", Help: "storage bench"},
+		makeLabelNames("idx", 2), makeLabelNames("grp", 1), makeLabelNames("ext", 1),
+		options...,
+	)
+	for i := 0; i < n; i++ {
+		col.Set(float64(i),
+			[]string{fmt.Sprintf("namespace_%d", i/1024), fmt.Sprintf("name_%d", i/16)},
+			[]string{fmt.Sprintf("condition_%d", i/4%4)},
+			fmt.Sprintf("status_%d", i%4),
+		)
+	}
+	return col
+}
+
+// heapInUse returns the live heap after a full collection.
+func heapInUse() uint64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// Memory retained per series by each storage backend (B/series, measured once while building the set), and the
+// cost of updating existing series.
+func Benchmark_DynamicGaugeCollector_Storage_Memory(b *testing.B) {
+	for _, backend := range storageBackends {
+		for _, n := range storageSeries {
+			b.Run(fmt.Sprintf("%s/series=%d", backend.name, n), func(b *testing.B) {
+				before := heapInUse()
+				col := newStorageCollector("bench_storage_mem", n, backend.options...)
+				retained := heapInUse() - before
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.ReportMetric(float64(retained)/float64(n), "B/series")
+
+				for i := 0; i < b.N; i++ {
+					j := i % n
+					col.Set(float64(i),
+						[]string{fmt.Sprintf("namespace_%d", j/1024), fmt.Sprintf("name_%d", j/16)},
+						[]string{fmt.Sprintf("condition_%d", j/4%4)},
+						fmt.Sprintf("status_%d", j%4),
+					)
+				}
+				b.StopTimer()
+				runtime.KeepAlive(col)
+			})
+		}
+	}
+}
+
+// Scrape time of each storage backend: every op gathers all series through a registry.
+func Benchmark_DynamicGaugeCollector_Storage_Scrape(b *testing.B) {
+	for _, backend := range storageBackends {
+		for _, n := range storageSeries {
+			b.Run(fmt.Sprintf("%s/series=%d", backend.name, n), func(b *testing.B) {
+				reg := prometheus.NewRegistry()
+				reg.MustRegister(newStorageCollector("bench_storage_scrape", n, backend.options...))
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.ReportMetric(float64(n), "series/op")
+
+				for i := 0; i < b.N; i++ {
+					if _, err := reg.Gather(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// Memory retained per series with and without label interning, for each storage backend.
+func Benchmark_DynamicGaugeCollector_Interning_Memory(b *testing.B) {
+	for _, backend := range storageBackends {
+		for _, interning := range []bool{false, true} {
+			options := backend.options
+			if interning {
+				options = append([]Option{WithLabelInterning()}, options...)
+			}
+			for _, n := range storageSeries {
+				b.Run(fmt.Sprintf("%s/interning=%t/series=%d", backend.name, interning, n), func(b *testing.B) {
+					var retained uint64
+					for i := 0; i < b.N; i++ {
+						before := heapInUse()
+						col := newStorageCollector("bench_interning_mem", n, options...)
+						retained += heapInUse() - before
+						runtime.KeepAlive(col)
+					}
+					b.ReportMetric(float64(retained)/float64(b.N)/float64(n), "B/series")
+				})
+			}
+		}
+	}
+}
+
+// DeleteBy on a secondary index against a full scan with DeleteWhere: every op deletes and restores the 16 series of
+// one node out of n.
+func Benchmark_DynamicGaugeCollector_DeleteBy_SecondaryIndex(b *testing.B) {
+	const perNode = 16
+	deletes := map[string]func(col *GaugeVecSet, node string){
+		"DeleteBy": func(col *GaugeVecSet, node string) {
+			col.DeleteBy("node", node)
+		},
+		"DeleteWhere": func(col *GaugeVecSet, node string) {
+			col.DeleteWhere(func(_, _, extraValues []string) bool { return extraValues[0] == node })
+		},
+	}
+	for _, name := range []string{"DeleteBy", "DeleteWhere"} {
+		for _, n := range []int{1000, 10000} {
+			b.Run(fmt.Sprintf("%s/series=%d", name, n), func(b *testing.B) {
+				col := NewGaugeVecSetWithOpts(
+					prometheus.GaugeOpts{Namespace: "bench_delete_by", Name: "dg_secondary", Help: "secondary bench"},
+					[]string{"namespace"}, []string{"pod"}, []string{"node"},
+					WithSecondaryIndex("node"),
+				)
+				write := func(i int) {
+					col.Set(1, []string{fmt.Sprintf("ns_%d", i%7)}, []string{fmt.Sprintf("pod_%d", i)},
+						fmt.Sprintf("node_%d", i/perNode))
+				}
+				for i := 0; i < n; i++ {
+					write(i)
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.ReportMetric(float64(n), "series/op")
+
+				for i := 0; i < b.N; i++ {
+					node := i % (n / perNode)
+					deletes[name](col, fmt.Sprintf("node_%d", node))
+					b.StopTimer()
+					for j := node * perNode; j < (node+1)*perNode; j++ {
+						write(j)
+					}
+					b.StartTimer()
+				}
+			})
+		}
+	}
+}
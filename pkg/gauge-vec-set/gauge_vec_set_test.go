@@ -149,6 +149,58 @@ testns_subsys_jobs_multi_grouped{cluster="c2",condition="Ready",phase="running",
 	assert.Equal(t, 0, col.DeleteByIndex("t1", "c1"))
 }
 
+// Ensure DeleteByIndexPrefix removes every index below a partial index tuple
+func Test_DynamicGaugeCollector_DeleteByIndexPrefix(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"jobs_prefix",
+		"help text (prefix)",
+		[]string{"controller", "namespace", "name"}, // index labels
+		[]string{"condition"},                       // group label
+		"status",                                    // extra label
+	)
+
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, []string{"c1", "ns1", "a"}, []string{"Ready"}, "True")
+	col.Set(1, []string{"c1", "ns1", "a"}, []string{"Synced"}, "True")
+	col.Set(1, []string{"c1", "ns1", "b"}, []string{"Ready"}, "True")
+	col.Set(1, []string{"c1", "ns2", "a"}, []string{"Ready"}, "True")
+	col.Set(1, []string{"c2", "ns1", "a"}, []string{"Ready"}, "True")
+
+	// controller + namespace prefix
+	assert.Equal(t, 3, col.DeleteByIndexPrefix("c1", "ns1"))
+
+	want := `
+# HELP testns_subsys_jobs_prefix help text (prefix)
+# TYPE testns_subsys_jobs_prefix gauge
+testns_subsys_jobs_prefix{condition="Ready",controller="c1",name="a",namespace="ns2",status="True"} 1
+testns_subsys_jobs_prefix{condition="Ready",controller="c2",name="a",namespace="ns1",status="True"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_jobs_prefix"))
+
+	// controller prefix
+	assert.Equal(t, 1, col.DeleteByIndexPrefix("c1"))
+	assert.Equal(t, 0, col.DeleteByIndexPrefix("c1"))
+
+	// full index behaves like DeleteByIndex
+	assert.Equal(t, 1, col.DeleteByIndexPrefix("c2", "ns1", "a"))
+	assert.Empty(t, col.indexes)
+	assert.Empty(t, col.indexTrie.root.children)
+
+	// re-populating after pruning works and the empty prefix removes everything
+	col.Set(1, []string{"c1", "ns1", "a"}, []string{"Ready"}, "True")
+	col.Set(1, []string{"c2", "ns1", "a"}, []string{"Ready"}, "True")
+	assert.Equal(t, 2, col.DeleteByIndexPrefix())
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(""), "testns_subsys_jobs_prefix"))
+
+	_, err := col.TryDeleteByIndexPrefix("c1", "ns1", "a", "extra")
+	assert.ErrorIs(t, err, ErrIndexArity)
+}
+
 // Ensures SetGrouped removes sibling series in the same (index, group).
 func Test_DynamicGaugeCollector_SetGrouped(t *testing.T) {
 	reg := prometheus.NewRegistry()
@@ -350,6 +402,79 @@ func Test_DynamicGaugeCollector_ArityValidationPanics(t *testing.T) {
 	})
 }
 
+func Test_DynamicGaugeCollector_TryArityErrors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"try_arity",
+		"help text",
+		[]string{"a", "b"}, // index has 2 labels
+		[]string{"grp"},    // 1 group
+		"x", "y", "z",      // 3 extra
+	)
+	require.NoError(t, reg.Register(col))
+
+	assert.ErrorIs(t, col.TrySet(1, []string{"onlyA"}, []string{"G"}, "xv", "yv", "zv"), ErrIndexArity)
+	assert.ErrorIs(t, col.TrySet(1, []string{"A", "B"}, []string{"G", "H"}, "xv", "yv", "zv"), ErrGroupArity)
+	assert.ErrorIs(t, col.TrySet(1, []string{"A", "B"}, []string{"G"}, "xv", "yv"), ErrExtraArity)
+	assert.ErrorIs(t, col.TrySetActiveInGroup(1, []string{"A"}, []string{"G"}, "xv", "yv", "zv"), ErrIndexArity)
+
+	// A valid series must survive a failed TrySetGroup: arity is checked before deleting.
+	require.NoError(t, col.TrySet(1, []string{"A", "B"}, []string{"G"}, "xv", "yv", "zv"))
+	assert.ErrorIs(t, col.TrySetGroup(1, []string{"A", "B"}, []string{"G"}, "xv"), ErrExtraArity)
+
+	_, err := col.TryDeleteByIndex("A")
+	assert.ErrorIs(t, err, ErrIndexArity)
+	_, err = col.TryDeleteByGroup([]string{"A", "B"}, "G", "H")
+	assert.ErrorIs(t, err, ErrGroupArity)
+
+	want := `
+# HELP testns_subsys_try_arity help text
+# TYPE testns_subsys_try_arity gauge
+testns_subsys_try_arity{a="A",b="B",grp="G",x="xv",y="yv",z="zv"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_try_arity"))
+
+	deleted, err := col.TryDeleteByGroup([]string{"A", "B"}, "G")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+}
+
+func Test_DynamicGaugeCollector_ErrorHandler(t *testing.T) {
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"handler",
+		"help text",
+		[]string{"a"},
+		[]string{"grp"},
+		"x",
+	)
+
+	var handled []error
+	col.SetErrorHandler(func(err error) { handled = append(handled, err) })
+
+	assert.NotPanics(t, func() {
+		col.Set(1, nil, []string{"G"}, "xv")
+		col.SetGroup(1, []string{"A"}, nil, "xv")
+		col.SetActiveInGroup(1, []string{"A"}, []string{"G"})
+		assert.Equal(t, 0, col.DeleteByIndex())
+	})
+	require.Len(t, handled, 4)
+	assert.ErrorIs(t, handled[0], ErrIndexArity)
+	assert.ErrorIs(t, handled[1], ErrGroupArity)
+	assert.ErrorIs(t, handled[2], ErrExtraArity)
+	assert.ErrorIs(t, handled[3], ErrIndexArity)
+
+	// nil restores the default panicking handler
+	col.SetErrorHandler(nil)
+	assert.Panics(t, func() {
+		col.Set(1, nil, []string{"G"}, "xv")
+	})
+}
+
 func Test_DynamicGaugeCollector_MetricNamePanics(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -418,24 +543,60 @@ func Test_DynamicGaugeCollector_LabelsWithHashCharacters(t *testing.T) {
 	)
 	require.NoError(t, reg.Register(col))
 
-	// Values containing '#' should be stripped
+	// Values containing the former separator character are exported unchanged
 	idx := []string{"t`1", "c`1"}
 	col.Set(1, idx, []string{"Re`ady"}, "run`ning")
+	// ... and don't collide with the same values without it
+	col.Set(2, []string{"t1", "c1"}, []string{"Ready"}, "running")
 
-	want := `
+	// '~' stands in for the backtick, which cannot appear in a raw string literal
+	want := strings.ReplaceAll(`
 # HELP testns_subsys_hashy help text
 # TYPE testns_subsys_hashy gauge
-testns_subsys_hashy{cluster="c1",condition="Ready",phase="running",tenant="t1"} 1
-`
+testns_subsys_hashy{cluster="c1",condition="Ready",phase="running",tenant="t1"} 2
+testns_subsys_hashy{cluster="c~1",condition="Re~ady",phase="run~ning",tenant="t~1"} 1
+`, "~", "`")
 	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_hashy"))
 
-	// Ensure DeleteByIndex works with input that still contains '#'
+	// Ensure DeleteByIndex only removes the exact tuple
 	assert.Equal(t, 1, col.DeleteByIndex("t`1", "c`1"))
+	assert.Equal(t, 1, col.DeleteByIndex("t1", "c1"))
 
 	// Metric should now be gone
 	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(""), "testns_subsys_hashy"))
 }
 
+// Ensure tuples that only differ in how the values are split never share a key
+func Test_DynamicGaugeCollector_KeyEncodingIsLossless(t *testing.T) {
+	cases := [][]string{
+		{"a`b"},
+		{"ab"},
+		{"a", "b"},
+		{"a`", "b"},
+		{"a", "`b"},
+		{"1:a", ""},
+		{"", "1:a"},
+		{"", ""},
+		{""},
+		{"10:", "x"},
+		{strings.Repeat("z", 1234), "12:"},
+	}
+
+	seen := make(map[string][]string, len(cases))
+	for _, values := range cases {
+		key := serialize(values)
+		if prev, exists := seen[key]; exists {
+			t.Fatalf("%q and %q share the key %q", prev, values, key)
+		}
+		seen[key] = values
+		assert.Equal(t, values, deserialize(key))
+	}
+
+	// The encoding is concatenative
+	assert.Equal(t, serialize([]string{"a", "b`", "c"}), serialize([]string{"a"})+serialize([]string{"b`", "c"}))
+	assert.Empty(t, deserialize(serialize(nil)))
+}
+
 // Run the test 50 times:
 // go test -race ./pkg/metrics -run 'TestDynamicGaugeCollector_ConcurrentSetDelete_NoRace' -count=50
 func Test_DynamicGaugeCollector_ConcurrentSetDelete_NoRace(t *testing.T) {
@@ -535,3 +696,116 @@ func Test_DynamicGaugeCollector_ConcurrentSetDelete_NoRace(t *testing.T) {
 	_, err := reg.Gather()
 	require.NoError(t, err)
 }
+
+// Ensure scrapes concurrent with group transitions observe exactly one active series per group:
+// never the siblings zeroed before the target is set (SetActiveInGroup) or the group deleted before the target is
+// recreated (SetGroup).
+func Test_DynamicGaugeCollector_ScrapeConsistentTransitions(t *testing.T) {
+	active := NewGaugeVecSet("testns", "subsys", "active", "help text", []string{"namespace"}, []string{"pod"}, "phase")
+	exclusive := NewGaugeVecSet("testns", "subsys", "exclusive", "help text", []string{"namespace"}, []string{"pod"}, "phase")
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(active))
+	require.NoError(t, reg.Register(exclusive))
+
+	pods := []string{"a", "b"}
+	phases := []string{"Pending", "Running", "Succeeded", "Failed"}
+	for _, pod := range pods {
+		active.SetActiveInGroup(1, []string{"ns1"}, []string{pod}, phases[0])
+		exclusive.SetGroup(1, []string{"ns1"}, []string{pod}, phases[0])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(time.Now().UnixNano() + int64(id)*7919))
+			for ctx.Err() == nil {
+				pod := []string{pods[r.Intn(len(pods))]}
+				phase := phases[r.Intn(len(phases))]
+				active.SetActiveInGroup(1, []string{"ns1"}, pod, phase)
+				exclusive.SetGroup(1, []string{"ns1"}, pod, phase)
+			}
+		}(i)
+	}
+
+	for ctx.Err() == nil {
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		require.Len(t, families, 2)
+		for _, family := range families {
+			activePerPod := make(map[string]int)
+			for _, m := range family.GetMetric() {
+				if m.GetGauge().GetValue() == 0 {
+					continue
+				}
+				for _, label := range m.GetLabel() {
+					if label.GetName() == "pod" {
+						activePerPod[label.GetValue()]++
+					}
+				}
+			}
+			for _, pod := range pods {
+				require.Equal(t, 1, activePerPod[pod], "%s: pod %s", family.GetName(), pod)
+			}
+		}
+	}
+	wg.Wait()
+}
+
+// Ensure concurrent exclusive writes on the same group are linearizable: exactly one series survives per group.
+// Writers are released together in rounds, and the group is checked after every round.
+// Run the test 50 times (writers only interleave with GOMAXPROCS > 1):
+// go test -race ./pkg/gauge-vec-set -run 'Test_DynamicGaugeCollector_ConcurrentExclusiveWrites' -count=50
+func Test_DynamicGaugeCollector_ConcurrentExclusiveWrites(t *testing.T) {
+	writes := map[string]func(col *GaugeVecSet, phase string){
+		"SetGroup": func(col *GaugeVecSet, phase string) {
+			col.SetGroup(1, []string{"ns1"}, []string{"a"}, phase)
+		},
+		"AddGroup": func(col *GaugeVecSet, phase string) {
+			col.AddGroup(1, []string{"ns1"}, []string{"a"}, phase)
+		},
+		"GroupHandle.SetGroup": func(col *GaugeVecSet, phase string) {
+			col.BindGroup([]string{"ns1"}, "a").SetGroup(1, phase)
+		},
+		"SetActiveInGroup": func(col *GaugeVecSet, phase string) {
+			col.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, phase)
+		},
+	}
+	phases := []string{"Pending", "Running", "Succeeded", "Failed", "Unknown", "Evicted", "Terminating", "Completed"}
+
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			col := NewGaugeVecSet("testns", "subsys", "exclusive", "help text", []string{"namespace"}, []string{"pod"}, "phase")
+
+			for round := 0; round < 200; round++ {
+				start := make(chan struct{})
+				var wg sync.WaitGroup
+				for _, phase := range phases {
+					wg.Add(1)
+					go func(phase string) {
+						defer wg.Done()
+						<-start
+						write(col, phase)
+					}(phase)
+				}
+				close(start)
+				wg.Wait()
+
+				var active int
+				for _, s := range col.GroupValues([]string{"ns1"}, "a") {
+					if s.Value != 0 {
+						active++
+					}
+				}
+				require.Equal(t, 1, active, "round %d", round)
+				if name != "SetActiveInGroup" {
+					require.Equal(t, 1, testutil.CollectAndCount(col), "round %d", round)
+				}
+			}
+		})
+	}
+}
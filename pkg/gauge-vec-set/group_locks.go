@@ -0,0 +1,98 @@
+package gauge_vec_set
+
+import (
+	"slices"
+	"sync"
+)
+
+// groupLockStripes is the number of locks the (index, group) tuples of a set are striped over.
+const groupLockStripes = 64
+
+// groupLocks serializes the exclusive writers of each (index, group) and excludes them from Collect.
+//
+// Exclusive writes (SetActiveInGroup, SetGroup, AddGroup, ...) hold the stripe of their group for writing during
+// the whole transition, which makes concurrent exclusive writes on the same group linearizable while writes on
+// groups of other stripes proceed in parallel. Collect of the gauge sets holds every stripe for reading, so it never
+// observes a transition halfway. Stripes are always acquired before VecSet.mu.
+type groupLocks [groupLockStripes]sync.RWMutex
+
+// stripe returns the lock guarding (indexKey, groupKey).
+func (l *groupLocks) stripe(indexKey, groupKey string) *sync.RWMutex {
+	return &l[stripeOf(indexKey, groupKey)]
+}
+
+// stripeOf returns the position of the stripe guarding (indexKey, groupKey), using FNV-1a over both keys.
+func stripeOf(indexKey, groupKey string) int {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(indexKey); i++ {
+		h = (h ^ uint32(indexKey[i])) * prime32
+	}
+	for i := 0; i < len(groupKey); i++ {
+		h = (h ^ uint32(groupKey[i])) * prime32
+	}
+	return int(h % groupLockStripes)
+}
+
+// lockBelow acquires the stripes of every group cached below prefix, together with the given stripes, and returns
+// them for unlockStripes. Groups created below prefix while the stripes are acquired are picked up by collecting the
+// stripes again once they are held, until no new stripe turns up.
+func (c *VecSet[T]) lockBelow(prefix []string, stripes ...int) []int {
+	stripes = c.stripesBelow(prefix, stripes)
+	for {
+		c.groupLocks.lockStripes(stripes)
+		current := c.stripesBelow(prefix, stripes)
+		if len(current) == len(stripes) {
+			return stripes
+		}
+		c.groupLocks.unlockStripes(stripes)
+		stripes = current
+	}
+}
+
+// stripesBelow returns the distinct stripes of every group cached below prefix together with stripes, in ascending
+// order.
+func (c *VecSet[T]) stripesBelow(prefix []string, stripes []int) []int {
+	stripes = slices.Clone(stripes)
+	c.mu.RLock()
+	for _, indexKey := range c.indexTrie.collect(prefix) {
+		for groupKey := range c.indexes[indexKey] {
+			stripes = append(stripes, stripeOf(indexKey, groupKey))
+		}
+	}
+	c.mu.RUnlock()
+	slices.Sort(stripes)
+	return slices.Compact(stripes)
+}
+
+// lockStripes acquires the given stripes for writing. Like rlockAll, it acquires them in ascending order, so
+// writers spanning several groups never deadlock with each other or with Collect.
+func (l *groupLocks) lockStripes(stripes []int) {
+	for _, i := range stripes {
+		l[i].Lock()
+	}
+}
+
+// unlockStripes releases the stripes acquired by lockStripes.
+func (l *groupLocks) unlockStripes(stripes []int) {
+	for _, i := range stripes {
+		l[i].Unlock()
+	}
+}
+
+// rlockAll acquires every stripe for reading.
+func (l *groupLocks) rlockAll() {
+	for i := range l {
+		l[i].RLock()
+	}
+}
+
+// runlockAll releases the stripes acquired by rlockAll.
+func (l *groupLocks) runlockAll() {
+	for i := range l {
+		l[i].RUnlock()
+	}
+}
@@ -0,0 +1,232 @@
+package gauge_vec_set
+
+// IndexHandle is a GaugeVecSet bound to one index label-values tuple.
+//
+// The index values are validated and serialized once in BindIndex, so operations on the handle only pay for the
+// group and extra values. Handles are cheap, safe for concurrent use and remain valid after the index is deleted;
+// subsequent writes simply recreate it.
+type IndexHandle struct {
+	set         *GaugeVecSet
+	indexValues []string
+	indexKey    string
+	// err is the validation error of an invalid binding; it is reported on every use of the handle.
+	err error
+}
+
+// GroupHandle is a GaugeVecSet bound to one (index, group) label-values tuple.
+//
+// The index and group values are validated and serialized once in BindGroup, so operations on the handle only
+// pay for the extra values.
+type GroupHandle struct {
+	set         *GaugeVecSet
+	indexValues []string
+	groupValues []string
+	indexKey    string
+	groupKey    string
+	// err is the validation error of an invalid binding; it is reported on every use of the handle.
+	err error
+}
+
+// BindIndex returns a handle for the given index values, similar to GaugeVec.CurryWith.
+// If the arity is wrong, the error handler is invoked and, should it return, every operation
+// on the handle reports the same error.
+func (c *GaugeVecSet) BindIndex(indexValues ...string) *IndexHandle {
+	h, err := c.TryBindIndex(indexValues...)
+	if err != nil {
+		c.handleError(err)
+		return &IndexHandle{set: c, err: err}
+	}
+	return h
+}
+
+// TryBindIndex is like BindIndex but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TryBindIndex(indexValues ...string) (*IndexHandle, error) {
+	if err := c.validateIndexValues(indexValues); err != nil {
+		return nil, err
+	}
+	indexValues = append([]string(nil), indexValues...)
+	return &IndexHandle{
+		set:         c,
+		indexValues: indexValues,
+		indexKey:    serialize(indexValues),
+	}, nil
+}
+
+// BindGroup returns a handle for the given (index, group) values.
+// If the arity is wrong, the error handler is invoked and, should it return, every operation
+// on the handle reports the same error.
+func (c *GaugeVecSet) BindGroup(indexValues []string, groupValues ...string) *GroupHandle {
+	h, err := c.TryBindGroup(indexValues, groupValues...)
+	if err != nil {
+		c.handleError(err)
+		return &GroupHandle{set: c, err: err}
+	}
+	return h
+}
+
+// TryBindGroup is like BindGroup but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TryBindGroup(indexValues []string, groupValues ...string) (*GroupHandle, error) {
+	if err := c.validateIndexValues(indexValues); err != nil {
+		return nil, err
+	}
+	if err := c.validateGroupValues(groupValues); err != nil {
+		return nil, err
+	}
+	indexValues = append([]string(nil), indexValues...)
+	groupValues = append([]string(nil), groupValues...)
+	return &GroupHandle{
+		set:         c,
+		indexValues: indexValues,
+		groupValues: groupValues,
+		indexKey:    serialize(indexValues),
+		groupKey:    serialize(groupValues),
+	}, nil
+}
+
+// BindGroup returns a handle for the given group values under the bound index.
+func (h *IndexHandle) BindGroup(groupValues ...string) *GroupHandle {
+	if h.err != nil {
+		h.set.handleError(h.err)
+		return &GroupHandle{set: h.set, err: h.err}
+	}
+	if err := h.set.validateGroupValues(groupValues); err != nil {
+		h.set.handleError(err)
+		return &GroupHandle{set: h.set, err: err}
+	}
+	groupValues = append([]string(nil), groupValues...)
+	return &GroupHandle{
+		set:         h.set,
+		indexValues: h.indexValues,
+		groupValues: groupValues,
+		indexKey:    h.indexKey,
+		groupKey:    serialize(groupValues),
+	}
+}
+
+// prepare validates the value, group and extra values and returns the group key and full label values.
+func (h *IndexHandle) prepare(
+	value float64, groupValues, extraValues []string,
+) (groupKey string, allValues []string, ok bool) {
+	err := h.err
+	if err == nil {
+		err = h.set.validateGroupValues(groupValues)
+	}
+	if err == nil {
+		err = h.set.validateExtraValues(extraValues)
+	}
+	if err == nil {
+		err = h.set.validateStateValue(value)
+	}
+	if err != nil {
+		h.set.handleError(err)
+		return "", nil, false
+	}
+	return serialize(groupValues), buildAllValues(h.indexValues, groupValues, extraValues), true
+}
+
+// Set is GaugeVecSet.Set for the bound index.
+func (h *IndexHandle) Set(value float64, groupValues []string, extraValues ...string) {
+	if groupKey, allValues, ok := h.prepare(value, groupValues, extraValues); ok {
+		if err := h.set.set(value, h.indexKey, groupKey, allValues); err != nil {
+			h.set.handleError(err)
+		}
+	}
+}
+
+// SetActiveInGroup is GaugeVecSet.SetActiveInGroup for the bound index.
+func (h *IndexHandle) SetActiveInGroup(value float64, groupValues []string, extraValues ...string) {
+	if groupKey, allValues, ok := h.prepare(value, groupValues, extraValues); ok {
+		if err := h.set.setActiveInGroup(value, h.indexKey, groupKey, allValues); err != nil {
+			h.set.handleError(err)
+		}
+	}
+}
+
+// SetGroup is GaugeVecSet.SetGroup for the bound index.
+func (h *IndexHandle) SetGroup(value float64, groupValues []string, extraValues ...string) {
+	if groupKey, allValues, ok := h.prepare(value, groupValues, extraValues); ok {
+		if err := h.set.setGroup(value, h.indexKey, groupKey, allValues); err != nil {
+			h.set.handleError(err)
+		}
+	}
+}
+
+// DeleteGroup is GaugeVecSet.DeleteByGroup for the bound index.
+func (h *IndexHandle) DeleteGroup(groupValues ...string) (deleted int) {
+	if len(h.set.groupLabels) == 0 {
+		return 0
+	}
+	err := h.err
+	if err == nil {
+		err = h.set.validateGroupValues(groupValues)
+	}
+	if err != nil {
+		h.set.handleError(err)
+		return 0
+	}
+	return h.set.deleteByGroupKey(h.indexKey, serialize(groupValues))
+}
+
+// Delete removes all series of the bound index. See GaugeVecSet.DeleteByIndex.
+func (h *IndexHandle) Delete() (deleted int) {
+	if h.err != nil {
+		h.set.handleError(h.err)
+		return 0
+	}
+	return h.set.deleteByIndexKey(h.indexValues, h.indexKey)
+}
+
+// prepare validates the value and extra values and returns the full label values.
+func (h *GroupHandle) prepare(value float64, extraValues []string) (allValues []string, ok bool) {
+	err := h.err
+	if err == nil {
+		err = h.set.validateExtraValues(extraValues)
+	}
+	if err == nil {
+		err = h.set.validateStateValue(value)
+	}
+	if err != nil {
+		h.set.handleError(err)
+		return nil, false
+	}
+	return buildAllValues(h.indexValues, h.groupValues, extraValues), true
+}
+
+// Set is GaugeVecSet.Set for the bound (index, group).
+func (h *GroupHandle) Set(value float64, extraValues ...string) {
+	if allValues, ok := h.prepare(value, extraValues); ok {
+		if err := h.set.set(value, h.indexKey, h.groupKey, allValues); err != nil {
+			h.set.handleError(err)
+		}
+	}
+}
+
+// SetActiveInGroup is GaugeVecSet.SetActiveInGroup for the bound (index, group).
+func (h *GroupHandle) SetActiveInGroup(value float64, extraValues ...string) {
+	if allValues, ok := h.prepare(value, extraValues); ok {
+		if err := h.set.setActiveInGroup(value, h.indexKey, h.groupKey, allValues); err != nil {
+			h.set.handleError(err)
+		}
+	}
+}
+
+// SetGroup is GaugeVecSet.SetGroup for the bound (index, group).
+func (h *GroupHandle) SetGroup(value float64, extraValues ...string) {
+	if allValues, ok := h.prepare(value, extraValues); ok {
+		if err := h.set.setGroup(value, h.indexKey, h.groupKey, allValues); err != nil {
+			h.set.handleError(err)
+		}
+	}
+}
+
+// Delete removes all series of the bound (index, group). See GaugeVecSet.DeleteByGroup.
+func (h *GroupHandle) Delete() (deleted int) {
+	if h.err != nil {
+		h.set.handleError(h.err)
+		return 0
+	}
+	if len(h.set.groupLabels) == 0 {
+		return 0
+	}
+	return h.set.deleteByGroupKey(h.indexKey, h.groupKey)
+}
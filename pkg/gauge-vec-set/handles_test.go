@@ -0,0 +1,93 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure bound handles behave like the corresponding GaugeVecSet operations
+func Test_DynamicGaugeCollector_BoundHandles(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"conditions_bound",
+		"help text",
+		[]string{"controller", "name", "namespace"}, // index
+		[]string{"condition"},                       // group
+		"status", "reason",                          // extra
+	)
+	require.NoError(t, reg.Register(col))
+
+	obj := col.BindIndex("ctrl", "obj", "ns")
+	obj.SetActiveInGroup(1, []string{"Ready"}, "True", "")
+	obj.SetActiveInGroup(1, []string{"Ready"}, "False", "bad_secret")
+	obj.SetGroup(1, []string{"Synchronized"}, "True", "")
+	obj.SetGroup(1, []string{"Synchronized"}, "False", "sync_pending")
+
+	other := col.BindGroup([]string{"ctrl", "other", "ns"}, "Ready")
+	other.Set(1, "True", "")
+	other.SetActiveInGroup(1, "False", "bad_secret")
+
+	want := `
+# HELP testns_subsys_conditions_bound help text
+# TYPE testns_subsys_conditions_bound gauge
+testns_subsys_conditions_bound{condition="Ready",controller="ctrl",name="obj",namespace="ns",reason="",status="True"} 0
+testns_subsys_conditions_bound{condition="Ready",controller="ctrl",name="obj",namespace="ns",reason="bad_secret",status="False"} 1
+testns_subsys_conditions_bound{condition="Synchronized",controller="ctrl",name="obj",namespace="ns",reason="sync_pending",status="False"} 1
+testns_subsys_conditions_bound{condition="Ready",controller="ctrl",name="other",namespace="ns",reason="",status="True"} 0
+testns_subsys_conditions_bound{condition="Ready",controller="ctrl",name="other",namespace="ns",reason="bad_secret",status="False"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_conditions_bound"))
+
+	// Handles and the set share the same index
+	assert.Equal(t, 1, obj.DeleteGroup("Synchronized"))
+	assert.Equal(t, 2, col.DeleteByGroup([]string{"ctrl", "obj", "ns"}, "Ready"))
+	assert.Equal(t, 0, obj.Delete())
+	assert.Equal(t, 2, other.Delete())
+	assert.Empty(t, col.indexes)
+
+	// Handles stay usable after their index was deleted
+	obj.BindGroup("Ready").SetGroup(1, "True", "")
+	assert.Equal(t, 1, col.DeleteByIndex("ctrl", "obj", "ns"))
+}
+
+func Test_DynamicGaugeCollector_BoundHandlesArity(t *testing.T) {
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"bound_arity",
+		"help text",
+		[]string{"a", "b"}, // index
+		[]string{"grp"},    // group
+		"x",                // extra
+	)
+
+	_, err := col.TryBindIndex("A")
+	assert.ErrorIs(t, err, ErrIndexArity)
+	_, err = col.TryBindGroup([]string{"A", "B"})
+	assert.ErrorIs(t, err, ErrGroupArity)
+
+	assert.Panics(t, func() { col.BindIndex("A") })
+	assert.Panics(t, func() { col.BindIndex("A", "B").Set(1, []string{"G"}) })
+	assert.Panics(t, func() { col.BindGroup([]string{"A", "B"}, "G").Set(1, "x", "y") })
+
+	// A handle created while a non-panicking handler is installed keeps reporting its error
+	var handled []error
+	col.SetErrorHandler(func(err error) { handled = append(handled, err) })
+
+	h := col.BindIndex("A")
+	h.Set(1, []string{"G"}, "x")
+	assert.Equal(t, 0, h.Delete())
+	require.Len(t, handled, 3)
+	for _, err := range handled {
+		assert.ErrorIs(t, err, ErrIndexArity)
+	}
+	assert.Empty(t, col.indexes)
+}
@@ -0,0 +1,358 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HierarchicalGaugeSet wraps a Prometheus GaugeVec whose labels form a hierarchy of ordered levels, e.g.
+//
+//	cluster -> namespace -> workload -> pod -> container
+//
+// and supports bulk operations at every level: DeleteAt removes all series below the values of a level, and
+// SetExclusiveAt makes one series the only active one below them.
+//
+// A level consists of one or more labels. Label order in the metric is the concatenation of all levels, from the
+// root, and label values follow the same order for all operations. An operation at level i takes the values of
+// levels 0 through i.
+//
+// The levels are kept in the 3-level index of VecSet: all levels but the last two form the index labels, whose
+// prefix tree serves operations at any of them, the second to last level forms the group labels and the last level
+// the extra labels. GaugeVecSet is the hierarchy of exactly these three levels.
+type HierarchicalGaugeSet struct {
+	VecSet[prometheus.Gauge]
+
+	// levels holds the labels of every level, from the root.
+	levels [][]string
+	// widths[i] is the number of labels in levels 0 through i.
+	widths []int
+	// indexLevels is the number of levels forming the index labels. The level after them is the group level,
+	// unless it is the last one.
+	indexLevels int
+}
+
+// NewHierarchicalGaugeSet constructs a HierarchicalGaugeSet from the full prometheus.GaugeOpts and the labels of
+// every level, from the root, and applies the given set-specific options.
+//
+// Every level needs at least one label, and no label may be used twice. Options referring to extra labels (e.g.
+// WithEnum) refer to the labels of the last level.
+//
+// Returns an *unregistered* collector; register it with a Prometheus registry yourself.
+// Example:
+//
+//	col := NewHierarchicalGaugeSet(
+//		prometheus.GaugeOpts{Namespace: ns, Name: "container_ready", Help: help},
+//		[][]string{{"cluster"}, {"namespace"}, {"workload"}, {"pod"}, {"container"}},
+//	)
+//	prometheus.MustRegister(col)
+func NewHierarchicalGaugeSet(opts prometheus.GaugeOpts, levels [][]string, options ...Option) *HierarchicalGaugeSet {
+	if len(levels) == 0 {
+		panic("NewHierarchicalGaugeSet: at least one level is required")
+	}
+	for i, level := range levels {
+		if len(level) == 0 {
+			panic(fmt.Sprintf("NewHierarchicalGaugeSet: level %d has no labels", i))
+		}
+	}
+
+	c := &HierarchicalGaugeSet{}
+	c.build(opts, levels, max(1, len(levels)-2), options)
+	return c
+}
+
+// build initializes c for the given levels, the first indexLevels of which form the index labels. Of the remaining
+// levels, at most two, the first is the group level if there are two, and the last one is the extra level.
+func (c *HierarchicalGaugeSet) build(opts prometheus.GaugeOpts, levels [][]string, indexLevels int, options []Option) {
+	validateLowercaseUnderscore(opts.Namespace)
+	validateLowercaseUnderscore(opts.Subsystem)
+	validateLowercaseUnderscore(opts.Name)
+
+	indexLabels := slices.Concat(levels[:indexLevels]...)
+	var groupLabels, extraLabels []string
+	switch rest := levels[indexLevels:]; len(rest) {
+	case 1:
+		extraLabels = rest[0]
+	case 2:
+		groupLabels, extraLabels = rest[0], rest[1]
+	}
+	allLabels := validateLabels(indexLabels, groupLabels, extraLabels)
+	validateConstLabels(opts.ConstLabels, allLabels)
+
+	var cfg config
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	var gv metricVec[prometheus.Gauge]
+	if cfg.standalone {
+		gv = newStandaloneGaugeVec(opts, allLabels)
+	} else {
+		gv = prometheus.NewGaugeVec(opts, allLabels)
+	}
+
+	c.VecSet = newVecSet[prometheus.Gauge](
+		prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name), gv, prometheus.NewGauge(opts),
+		indexLabels, groupLabels, extraLabels,
+	)
+	c.levels = levels
+	c.widths = make([]int, len(levels))
+	for i, level := range levels {
+		c.widths[i] = len(level)
+		if i > 0 {
+			c.widths[i] += c.widths[i-1]
+		}
+	}
+	c.indexLevels = indexLevels
+	c.exclusive = true
+
+	c.errorHandler = cfg.errorHandler
+	c.ttl = cfg.ttl
+	if cfg.now != nil {
+		c.now = cfg.now
+	}
+	c.SetLimits(cfg.limits)
+	c.SetLabelInterning(cfg.interning)
+	for _, label := range cfg.secondary {
+		c.AddSecondaryIndex(label)
+	}
+	if cfg.selfMetrics {
+		c.self = c.SelfMetrics()
+	}
+	if cfg.enumLabel != "" {
+		c.declareEnum(cfg.enumLabel, cfg.enumStates...)
+	}
+	if cfg.stateSet {
+		c.declareStateSet()
+	}
+}
+
+// Levels returns the labels of every level, from the root.
+func (c *HierarchicalGaugeSet) Levels() [][]string {
+	levels := make([][]string, len(c.levels))
+	for i, level := range c.levels {
+		levels[i] = slices.Clone(level)
+	}
+	return levels
+}
+
+// validateLevel ensures level is one of the levels of the set. The group level of a GaugeVecSet without group labels
+// has no labels to operate on and is rejected too.
+func (c *HierarchicalGaugeSet) validateLevel(level int) error {
+	if level < 0 || level >= len(c.levels) {
+		return fmt.Errorf("%w: %d, expected a level between 0 and %d", ErrUnknownLevel, level, len(c.levels)-1)
+	}
+	if level >= c.indexLevels && level < len(c.levels)-1 && len(c.groupLabels) == 0 {
+		return fmt.Errorf("%w: %d is the group level of a set without group labels", ErrUnknownLevel, level)
+	}
+	return nil
+}
+
+// validateLevelValues ensures values holds a value for every label of levels 0 through level.
+func (c *HierarchicalGaugeSet) validateLevelValues(level int, values []string) error {
+	if err := c.validateLevel(level); err != nil {
+		return err
+	}
+	if len(values) != c.widths[level] {
+		return arityError(ErrLevelArity, "level", slices.Concat(c.levels[:level+1]...), len(values))
+	}
+	return nil
+}
+
+// prepare validates a write of value to the series identified by values and returns its keys in the nested index
+// and a copy of its label values.
+func (c *HierarchicalGaugeSet) prepare(
+	value float64, values []string,
+) (indexKey, groupKey string, allValues []string, err error) {
+	if err := c.validateLevelValues(len(c.levels)-1, values); err != nil {
+		return "", "", nil, err
+	}
+	groupEnd := len(c.indexLabels) + len(c.groupLabels)
+	if err := c.validateExtraValues(values[groupEnd:]); err != nil {
+		return "", "", nil, err
+	}
+	if err := c.validateStateValue(value); err != nil {
+		return "", "", nil, err
+	}
+	return serialize(values[:len(c.indexLabels)]), serialize(values[len(c.indexLabels):groupEnd]),
+		slices.Clone(values), nil
+}
+
+// Set assigns the Gauge value for the series identified by the values of all levels.
+// This does not modify any other series. Use SetExclusiveAt to enforce exclusivity below a level.
+func (c *HierarchicalGaugeSet) Set(value float64, values ...string) {
+	if err := c.TrySet(value, values...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySet is like Set but returns an error instead of invoking the error handler.
+func (c *HierarchicalGaugeSet) TrySet(value float64, values ...string) error {
+	indexKey, groupKey, allValues, err := c.prepare(value, values)
+	if err != nil {
+		return err
+	}
+	return c.set(value, indexKey, groupKey, allValues)
+}
+
+// SetExclusiveAt sets the series identified by the values of all levels to `value` and zeroes **all other series**
+// sharing its values of levels 0 through level. Exclusivity at the last level is a plain Set, and at the group
+// level of a GaugeVecSet the same as SetActiveInGroup.
+//
+// Example: with the levels cluster -> namespace -> workload -> pod -> container, SetExclusiveAt(2, 1, values...)
+// marks the given container as the only active one of its workload.
+func (c *HierarchicalGaugeSet) SetExclusiveAt(level int, value float64, values ...string) {
+	if err := c.TrySetExclusiveAt(level, value, values...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySetExclusiveAt is like SetExclusiveAt but returns an error instead of invoking the error handler.
+func (c *HierarchicalGaugeSet) TrySetExclusiveAt(level int, value float64, values ...string) error {
+	if err := c.validateLevel(level); err != nil {
+		return err
+	}
+	indexKey, groupKey, allValues, err := c.prepare(value, values)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case level < c.indexLevels:
+		return c.setExclusiveBelow(value, allValues[:c.widths[level]], indexKey, groupKey, allValues)
+	case level == len(c.levels)-1:
+		return c.set(value, indexKey, groupKey, allValues)
+	default:
+		return c.setActiveInGroup(value, indexKey, groupKey, allValues)
+	}
+}
+
+// DeleteAt removes all series whose values of levels 0 through level equal values.
+// Returns the number of deleted series.
+//
+// The deletion holds the locks of every group it removes, so Collect never observes it halfway.
+func (c *HierarchicalGaugeSet) DeleteAt(level int, values ...string) (deleted int) {
+	deleted, err := c.TryDeleteAt(level, values...)
+	if err != nil {
+		c.handleError(err)
+	}
+	return deleted
+}
+
+// TryDeleteAt is like DeleteAt but returns an error instead of invoking the error handler.
+func (c *HierarchicalGaugeSet) TryDeleteAt(level int, values ...string) (deleted int, err error) {
+	if err := c.validateLevelValues(level, values); err != nil {
+		return 0, err
+	}
+
+	indexLabels := len(c.indexLabels)
+	switch {
+	case level < c.indexLevels:
+		return c.deleteByIndexPrefix(values), nil
+	case level == len(c.levels)-1:
+		groupEnd := indexLabels + len(c.groupLabels)
+		return c.deleteByFullKey(serialize(values[:indexLabels]), serialize(values[indexLabels:groupEnd]),
+			serialize(values)), nil
+	default:
+		return c.deleteByGroupKey(serialize(values[:indexLabels]), serialize(values[indexLabels:])), nil
+	}
+}
+
+// set assigns value to the series identified by allValues and caches it under (indexKey, groupKey).
+func (c *HierarchicalGaugeSet) set(value float64, indexKey, groupKey string, allValues []string) error {
+	ref, err := c.admit(indexKey, groupKey, allValues)
+	if err != nil {
+		return err
+	}
+	g := c.child(ref)
+	g.Set(value)
+	c.cacheWithKeys(ref, g)
+	return nil
+}
+
+// setActiveInGroup sets the series identified by allValues to value and zeroes its siblings in (indexKey, groupKey).
+// If the target is folded into an overflow series, the siblings of the overflow series are zeroed instead.
+//
+// The transition holds the group's lock, so Collect never observes a group with the siblings zeroed but the target
+// not yet set, and concurrent exclusive writes on the same group are linearizable.
+func (c *HierarchicalGaugeSet) setActiveInGroup(value float64, indexKey, groupKey string, allValues []string) error {
+	if len(c.groupLabels) == 0 {
+		return c.set(value, indexKey, groupKey, allValues)
+	}
+
+	groupLock := c.groupLocks.stripe(indexKey, groupKey)
+	groupLock.Lock()
+	defer groupLock.Unlock()
+
+	c.mu.Lock()
+	ref, err := c.admitLocked(indexKey, groupKey, allValues)
+	siblings := c.siblingsLocked(ref.indexKey, ref.groupKey, ref.fullKey)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// No locks but the group's are held during Prometheus calls.
+	for _, sibling := range siblings {
+		sibling.metric.Set(0)
+	}
+
+	// Set target and cache before materializing the other states, so they count against the limits after it.
+	g := c.child(ref)
+	g.Set(value)
+	c.cacheWithKeys(ref, g)
+	if c.enum != nil && !ref.folded {
+		c.materializeStates(ref.indexKey, ref.groupKey, ref.allValues)
+	}
+	c.touchGroup(ref.indexKey, ref.groupKey)
+	return nil
+}
+
+// setExclusiveBelow sets the series identified by allValues to value and zeroes all other series whose index values
+// start with prefix.
+//
+// Like setActiveInGroup, the transition holds the locks of the target group and of every group cached below prefix,
+// so Collect never observes it halfway.
+func (c *HierarchicalGaugeSet) setExclusiveBelow(
+	value float64, prefix []string, indexKey, groupKey string, allValues []string,
+) error {
+	stripes := c.lockBelow(prefix, stripeOf(indexKey, groupKey))
+	defer c.groupLocks.unlockStripes(stripes)
+
+	c.mu.Lock()
+	ref, err := c.admitLocked(indexKey, groupKey, allValues)
+	var siblings []indexedSeries[prometheus.Gauge]
+	var groups []seriesKeys
+	for _, key := range c.indexTrie.collect(prefix) {
+		for groupKey, group := range c.indexes[key] {
+			groups = append(groups, seriesKeys{indexKey: key, groupKey: groupKey})
+			for fullKey, s := range group {
+				if fullKey != ref.fullKey {
+					siblings = append(siblings, s)
+				}
+			}
+		}
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// No locks but the groups' are held during Prometheus calls.
+	for _, sibling := range siblings {
+		sibling.metric.Set(0)
+	}
+
+	g := c.child(ref)
+	g.Set(value)
+	c.cacheWithKeys(ref, g)
+	if c.enum != nil && !ref.folded {
+		c.materializeStates(ref.indexKey, ref.groupKey, ref.allValues)
+	}
+	c.touchGroup(ref.indexKey, ref.groupKey)
+	for _, group := range groups {
+		c.touchGroup(group.indexKey, group.groupKey)
+	}
+	return nil
+}
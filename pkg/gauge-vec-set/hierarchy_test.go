@@ -0,0 +1,289 @@
+package gauge_vec_set
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure the levels are mapped onto the index, group and extra labels
+func Test_DynamicGaugeCollector_Hierarchy_Levels(t *testing.T) {
+	col := NewHierarchicalGaugeSet(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ready", Help: "help text"},
+		[][]string{{"cluster"}, {"namespace"}, {"workload"}, {"pod"}, {"container"}},
+	)
+
+	assert.Equal(t, []string{"cluster", "namespace", "workload"}, col.indexLabels)
+	assert.Equal(t, []string{"pod"}, col.groupLabels)
+	assert.Equal(t, []string{"container"}, col.extraLabels)
+	assert.Equal(t, [][]string{{"cluster"}, {"namespace"}, {"workload"}, {"pod"}, {"container"}}, col.Levels())
+
+	two := NewHierarchicalGaugeSet(prometheus.GaugeOpts{Name: "two", Help: "help text"}, [][]string{{"a", "b"}, {"c"}})
+	assert.Equal(t, []string{"a", "b"}, two.indexLabels)
+	assert.Empty(t, two.groupLabels)
+	assert.Equal(t, []string{"c"}, two.extraLabels)
+}
+
+// Ensure DeleteAt removes the series below the given values at every level
+func Test_DynamicGaugeCollector_Hierarchy_DeleteAt(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewHierarchicalGaugeSet(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ready", Help: "help text"},
+		[][]string{{"cluster"}, {"namespace"}, {"workload"}, {"pod"}, {"container"}},
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, "eu1", "ns1", "api", "api-1", "app")
+	col.Set(1, "eu1", "ns1", "api", "api-1", "sidecar")
+	col.Set(1, "eu1", "ns1", "api", "api-2", "app")
+	col.Set(1, "eu1", "ns1", "web", "web-1", "app")
+	col.Set(1, "eu1", "ns2", "api", "api-1", "app")
+	col.Set(1, "us1", "ns1", "api", "api-1", "app")
+
+	assert.Equal(t, 1, col.DeleteAt(4, "eu1", "ns1", "api", "api-1", "sidecar"))
+	assert.Equal(t, 0, col.DeleteAt(4, "eu1", "ns1", "api", "api-1", "sidecar"))
+	assert.Equal(t, 1, col.DeleteAt(3, "eu1", "ns1", "api", "api-2"))
+	assert.Equal(t, 1, col.DeleteAt(2, "eu1", "ns1", "web"))
+
+	expected := `
+# HELP testns_subsys_ready help text
+# TYPE testns_subsys_ready gauge
+testns_subsys_ready{cluster="eu1",container="app",namespace="ns1",pod="api-1",workload="api"} 1
+testns_subsys_ready{cluster="eu1",container="app",namespace="ns2",pod="api-1",workload="api"} 1
+testns_subsys_ready{cluster="us1",container="app",namespace="ns1",pod="api-1",workload="api"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "testns_subsys_ready"))
+
+	assert.Equal(t, 2, col.DeleteAt(0, "eu1"))
+	assert.Equal(t, 1, col.DeleteAt(1, "us1", "ns1"))
+	assert.Empty(t, col.indexes)
+	assert.Empty(t, col.indexTrie.root.children)
+}
+
+// Ensure SetExclusiveAt zeroes the other series below the given level only
+func Test_DynamicGaugeCollector_Hierarchy_SetExclusiveAt(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewHierarchicalGaugeSet(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ready", Help: "help text"},
+		[][]string{{"cluster"}, {"namespace"}, {"workload"}, {"pod"}, {"container"}},
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, "eu1", "ns1", "api", "api-1", "app")
+	col.Set(1, "eu1", "ns1", "api", "api-1", "sidecar")
+	col.Set(1, "eu1", "ns1", "api", "api-2", "app")
+	col.Set(1, "eu1", "ns1", "web", "web-1", "app")
+	col.Set(1, "eu1", "ns2", "api", "api-1", "app")
+	col.Set(1, "us1", "ns1", "api", "api-1", "app")
+
+	// workload level: spans several pods (groups)
+	col.SetExclusiveAt(2, 1, "eu1", "ns1", "api", "api-3", "app")
+	// pod level: the group, within another namespace
+	col.SetExclusiveAt(3, 2, "eu1", "ns2", "api", "api-1", "sidecar")
+	// container level: a plain Set
+	col.SetExclusiveAt(4, 3, "us1", "ns1", "api", "api-1", "sidecar")
+
+	expected := `
+# HELP testns_subsys_ready help text
+# TYPE testns_subsys_ready gauge
+testns_subsys_ready{cluster="eu1",container="app",namespace="ns1",pod="api-1",workload="api"} 0
+testns_subsys_ready{cluster="eu1",container="app",namespace="ns1",pod="api-2",workload="api"} 0
+testns_subsys_ready{cluster="eu1",container="app",namespace="ns1",pod="api-3",workload="api"} 1
+testns_subsys_ready{cluster="eu1",container="app",namespace="ns1",pod="web-1",workload="web"} 1
+testns_subsys_ready{cluster="eu1",container="app",namespace="ns2",pod="api-1",workload="api"} 0
+testns_subsys_ready{cluster="eu1",container="sidecar",namespace="ns1",pod="api-1",workload="api"} 0
+testns_subsys_ready{cluster="eu1",container="sidecar",namespace="ns2",pod="api-1",workload="api"} 2
+testns_subsys_ready{cluster="us1",container="app",namespace="ns1",pod="api-1",workload="api"} 1
+testns_subsys_ready{cluster="us1",container="sidecar",namespace="ns1",pod="api-1",workload="api"} 3
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "testns_subsys_ready"))
+
+	// cluster level: a prefix of the index labels
+	col.SetExclusiveAt(0, 1, "us1", "ns2", "db", "db-1", "app")
+	assert.Equal(t, 1.0, testutil.ToFloat64(col.metric.WithLabelValues("us1", "ns2", "db", "db-1", "app")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(col.metric.WithLabelValues("us1", "ns1", "api", "api-1", "sidecar")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(col.metric.WithLabelValues("eu1", "ns1", "web", "web-1", "app")))
+}
+
+// Ensure GaugeVecSet exposes its index, group and extra labels as levels 0, 1 and 2
+func Test_DynamicGaugeCollector_Hierarchy_GaugeVecSet(t *testing.T) {
+	col := NewGaugeVecSet("testns", "subsys", "conditions", "help text",
+		[]string{"namespace", "name"}, []string{"condition"}, "status")
+	col.Set(1, []string{"prod", "a"}, []string{"Ready"}, "True")
+	col.Set(1, []string{"prod", "a"}, []string{"Synced"}, "True")
+	col.Set(1, []string{"prod", "b"}, []string{"Ready"}, "True")
+
+	assert.Equal(t, [][]string{{"namespace", "name"}, {"condition"}, {"status"}}, col.Levels())
+
+	col.SetExclusiveAt(1, 1, "prod", "a", "Ready", "False")
+	extra, value, ok := col.ActiveInGroup([]string{"prod", "a"}, "Ready")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"False"}, extra)
+	assert.Equal(t, 1.0, value)
+
+	col.SetExclusiveAt(0, 1, "prod", "a", "Degraded", "True")
+	_, _, ok = col.ActiveInGroup([]string{"prod", "a"}, "Synced")
+	assert.False(t, ok)
+	value, _ = col.Get([]string{"prod", "b"}, []string{"Ready"}, "True")
+	assert.Equal(t, 1.0, value)
+
+	assert.Equal(t, 1, col.DeleteAt(2, "prod", "a", "Ready", "True"))
+	assert.Equal(t, 1, col.DeleteAt(1, "prod", "a", "Ready"))
+	assert.Equal(t, 2, col.DeleteAt(0, "prod", "a"))
+	assert.Equal(t, [][]string{{"prod", "b"}}, col.Indexes())
+}
+
+// Ensure a GaugeVecSet without group labels rejects operations at the empty group level
+func Test_DynamicGaugeCollector_Hierarchy_GaugeVecSet_NoGroup(t *testing.T) {
+	col := NewGaugeVecSet("testns", "subsys", "jobs", "help text",
+		[]string{"namespace", "name"}, // index
+		nil,                           // group
+		"phase",                       // extra
+	)
+	col.Set(1, []string{"prod", "a"}, nil, "Running")
+	col.Set(0, []string{"prod", "a"}, nil, "Pending")
+	col.Set(1, []string{"prod", "b"}, nil, "Running")
+
+	_, err := col.TryDeleteAt(1, "prod", "a")
+	assert.ErrorIs(t, err, ErrUnknownLevel)
+	assert.ErrorIs(t, col.TrySetExclusiveAt(1, 1, "prod", "a", "Failed"), ErrUnknownLevel)
+	assert.Equal(t, 3, testutil.CollectAndCount(col))
+
+	col.SetExclusiveAt(0, 1, "prod", "a", "Failed")
+	value, _ := col.Get([]string{"prod", "a"}, nil, "Running")
+	assert.Equal(t, 0.0, value)
+	assert.Equal(t, 1, col.DeleteAt(2, "prod", "a", "Pending"))
+	assert.Equal(t, 2, col.DeleteAt(0, "prod", "a"))
+	assert.Equal(t, [][]string{{"prod", "b"}}, col.Indexes())
+}
+
+// Ensure invalid levels and values are rejected
+func Test_DynamicGaugeCollector_Hierarchy_Invalid(t *testing.T) {
+	col := NewHierarchicalGaugeSet(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ready", Help: "help text"},
+		[][]string{{"cluster"}, {"namespace"}, {"workload"}, {"pod"}, {"container"}},
+	)
+
+	col.Set(1, "eu1", "ns1", "api", "api-1", "app")
+	col.Set(1, "eu1", "ns1", "api", "api-1", "sidecar")
+	col.Set(1, "eu1", "ns1", "api", "api-2", "app")
+	col.Set(1, "eu1", "ns1", "web", "web-1", "app")
+	col.Set(1, "eu1", "ns2", "api", "api-1", "app")
+	col.Set(1, "us1", "ns1", "api", "api-1", "app")
+
+	_, err := col.TryDeleteAt(5, "eu1")
+	assert.ErrorIs(t, err, ErrUnknownLevel)
+	_, err = col.TryDeleteAt(-1)
+	assert.ErrorIs(t, err, ErrUnknownLevel)
+	_, err = col.TryDeleteAt(1, "eu1")
+	assert.ErrorIs(t, err, ErrLevelArity)
+	assert.ErrorIs(t, col.TrySet(1, "eu1", "ns1"), ErrLevelArity)
+	assert.ErrorIs(t, col.TrySetExclusiveAt(7, 1, "eu1", "ns1", "api", "api-1", "app"), ErrUnknownLevel)
+	assert.ErrorIs(t, col.TrySetExclusiveAt(1, 1, "eu1", "ns1"), ErrLevelArity)
+	assert.Equal(t, 6, testutil.CollectAndCount(col))
+
+	opts := prometheus.GaugeOpts{Name: "invalid", Help: "help text"}
+	assert.Panics(t, func() { NewHierarchicalGaugeSet(opts, nil) }, "no levels")
+	assert.Panics(t, func() { NewHierarchicalGaugeSet(opts, [][]string{{"a"}, {}}) }, "empty level")
+	assert.Panics(t, func() { NewHierarchicalGaugeSet(opts, [][]string{{"a"}, {"a"}}) }, "duplicate label")
+}
+
+// Ensure scrapes observe a namespace either before or after an exclusive write spanning its pods, never a mix of both
+func Test_DynamicGaugeCollector_Hierarchy_SetExclusiveAt_ScrapeConsistent(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewHierarchicalGaugeSet(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ready", Help: "help text"},
+		[][]string{{"cluster"}, {"namespace"}, {"workload"}, {"pod"}, {"container"}},
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, "eu1", "ns1", "api", "api-1", "app")
+	col.Set(1, "eu1", "ns1", "api", "api-1", "sidecar")
+	col.Set(1, "eu1", "ns1", "api", "api-2", "app")
+	col.Set(1, "eu1", "ns1", "web", "web-1", "app")
+	col.Set(1, "eu1", "ns2", "api", "api-1", "app")
+	col.Set(1, "us1", "ns1", "api", "api-1", "app")
+	col.DeleteAt(0, "us1")
+	col.SetExclusiveAt(1, 1, "eu1", "ns1", "api", "api-1", "app")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pods := []string{"api-1", "api-2", "api-3", "api-4"}
+		for i := 0; ctx.Err() == nil; i++ {
+			col.SetExclusiveAt(1, 1, "eu1", "ns1", "api", pods[i%len(pods)], "app")
+		}
+	}()
+
+	for ctx.Err() == nil {
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		require.Len(t, families, 1)
+		active := 0
+		for _, m := range families[0].GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["cluster"] == "eu1" && labels["namespace"] == "ns1" && m.GetGauge().GetValue() != 0 {
+				active++
+			}
+		}
+		require.Equal(t, 1, active)
+	}
+	wg.Wait()
+}
+
+// Ensure scrapes observe an index either before or after DeleteAt, or any other index deletion, removes it, never a
+// mix of both
+func Test_DynamicGaugeCollector_Hierarchy_DeleteAt_ScrapeConsistent(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewGaugeVecSet("testns", "subsys", "conditions", "help text",
+		[]string{"namespace", "name"}, // index
+		[]string{"condition"},         // group
+		"status",                      // extra
+	)
+	require.NoError(t, reg.Register(col))
+	desired := []Series{
+		{GroupValues: []string{"Ready"}, ExtraValues: []string{"True"}, Value: 1},
+		{GroupValues: []string{"Synced"}, ExtraValues: []string{"True"}, Value: 1},
+		{GroupValues: []string{"Degraded"}, ExtraValues: []string{"False"}, Value: 1},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	deletes := []func(){
+		func() { col.DeleteAt(0, "prod", "nginx") },
+		func() { col.DeleteByIndex("prod", "nginx") },
+		func() { col.DeleteByIndexPrefix("prod") },
+		func() { col.BindIndex("prod", "nginx").Delete() },
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ctx.Err() == nil; i++ {
+			col.ReplaceIndex([]string{"prod", "nginx"}, desired)
+			deletes[i%len(deletes)]()
+		}
+	}()
+
+	for ctx.Err() == nil {
+		count := testutil.CollectAndCount(col)
+		require.Contains(t, []int{0, len(desired)}, count)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,62 @@
+package gauge_vec_set
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HistogramVecSet wraps a Prometheus HistogramVec with the index/group bookkeeping of VecSet,
+// so per-object histograms can be removed in bulk with DeleteByIndex, DeleteByIndexPrefix and DeleteByGroup.
+type HistogramVecSet struct {
+	VecSet[prometheus.Observer]
+}
+
+// NewHistogramVecSet constructs a HistogramVecSet from the given options.
+// The label parameters have the same meaning as for NewGaugeVecSet.
+//
+// Returns an *unregistered* collector; register it with a Prometheus registry yourself.
+func NewHistogramVecSet(
+	opts prometheus.HistogramOpts,
+	indexLabels []string,
+	groupLabels []string,
+	extraLabels ...string,
+) *HistogramVecSet {
+	validateLowercaseUnderscore(opts.Namespace)
+	validateLowercaseUnderscore(opts.Subsystem)
+	validateLowercaseUnderscore(opts.Name)
+
+	allLabels := validateLabels(indexLabels, groupLabels, extraLabels)
+
+	hv := prometheus.NewHistogramVec(opts, allLabels)
+
+	return &HistogramVecSet{
+		VecSet: newVecSet[prometheus.Observer](
+			prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name), hv, prometheus.NewHistogram(opts),
+			indexLabels, groupLabels, extraLabels,
+		),
+	}
+}
+
+// Observe adds a single observation to the histogram identified by (index, group, extra).
+func (c *HistogramVecSet) Observe(value float64, indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TryObserve(value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TryObserve is like Observe but returns an error instead of invoking the error handler.
+func (c *HistogramVecSet) TryObserve(
+	value float64, indexValues []string, groupValues []string, extraValues ...string,
+) error {
+	return observe(&c.VecSet, value, indexValues, groupValues, extraValues)
+}
+
+// observe records value on the observer identified by (index, group, extra).
+// Shared by HistogramVecSet and SummaryVecSet.
+func observe(c *VecSet[prometheus.Observer], value float64, indexValues, groupValues, extraValues []string) error {
+	observer, err := c.TryWithLabelValues(indexValues, groupValues, extraValues...)
+	if err != nil {
+		return err
+	}
+	observer.Observe(value)
+	return nil
+}
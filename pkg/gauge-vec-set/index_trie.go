@@ -0,0 +1,108 @@
+package gauge_vec_set
+
+// indexTrie is a prefix tree over index label values.
+//
+// Each level of the tree corresponds to one index label, so a node at depth d represents
+// every index whose first d values match the path to it. Leaves (depth == len(indexLabels))
+// hold the serialized indexKey used by the nested indexes map, which lets partial-prefix
+// deletions find the matching index buckets without scanning unrelated ones.
+type indexTrie struct {
+	root *indexTrieNode
+}
+
+type indexTrieNode struct {
+	children map[string]*indexTrieNode
+	// indexKey is set on leaf nodes only.
+	indexKey string
+	leaf     bool
+}
+
+func newIndexTrie() *indexTrie {
+	return &indexTrie{root: &indexTrieNode{}}
+}
+
+// insert records indexKey under the path described by indexValues.
+func (t *indexTrie) insert(indexValues []string, indexKey string) {
+	node := t.root
+	for _, v := range indexValues {
+		if node.children == nil {
+			node.children = make(map[string]*indexTrieNode)
+		}
+		child, ok := node.children[v]
+		if !ok {
+			child = &indexTrieNode{}
+			node.children[v] = child
+		}
+		node = child
+	}
+	node.leaf = true
+	node.indexKey = indexKey
+}
+
+// remove deletes the leaf at indexValues and prunes any ancestors left without children.
+func (t *indexTrie) remove(indexValues []string) {
+	t.root.remove(indexValues)
+}
+
+// remove returns true if the node became empty and can be dropped by its parent.
+func (n *indexTrieNode) remove(indexValues []string) bool {
+	if len(indexValues) == 0 {
+		n.leaf = false
+		n.indexKey = ""
+		return len(n.children) == 0
+	}
+	child, ok := n.children[indexValues[0]]
+	if !ok {
+		return false
+	}
+	if child.remove(indexValues[1:]) {
+		delete(n.children, indexValues[0])
+	}
+	return !n.leaf && len(n.children) == 0
+}
+
+// collect returns the indexKeys of all leaves below the node addressed by prefix.
+func (t *indexTrie) collect(prefix []string) []string {
+	node := t.root
+	for _, v := range prefix {
+		child, ok := node.children[v]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+
+	var keys []string
+	var walk func(n *indexTrieNode)
+	walk = func(n *indexTrieNode) {
+		if n.leaf {
+			keys = append(keys, n.indexKey)
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	return keys
+}
+
+// collectMatching returns the indexKeys of all leaves whose path is matched value by value, depth being the position
+// of the value in the index values. Subtrees below a non-matching value are skipped. A nil match matches every leaf.
+func (t *indexTrie) collectMatching(match func(depth int, value string) bool) []string {
+	var keys []string
+	var walk func(n *indexTrieNode, depth int)
+	walk = func(n *indexTrieNode, depth int) {
+		if n.leaf {
+			keys = append(keys, n.indexKey)
+		}
+		for value, child := range n.children {
+			if match == nil || match(depth, value) {
+				walk(child, depth+1)
+			}
+		}
+	}
+	walk(t.root, 0)
+
+	return keys
+}
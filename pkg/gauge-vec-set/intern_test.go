@@ -0,0 +1,77 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure label values repeated across series share one copy with WithLabelInterning.
+// strings.Clone stands in for values decoded from separate objects.
+func Test_DynamicGaugeCollector_LabelInterning(t *testing.T) {
+	for _, standalone := range []bool{false, true} {
+		name := "gauge_vec"
+		if standalone {
+			name = "standalone"
+		}
+		t.Run(name, func(t *testing.T) {
+			options := []Option{WithLabelInterning()}
+			if standalone {
+				options = append(options, WithStandaloneStorage())
+			}
+			reg := prometheus.NewRegistry()
+			col := NewGaugeVecSetWithOpts(
+				prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+				[]string{"namespace"}, // index
+				[]string{"pod"},       // group
+				[]string{"phase"},     // extra
+				options...,
+			)
+			require.NoError(t, reg.Register(col))
+
+			col.Set(1, []string{strings.Clone("ns1")}, []string{strings.Clone("pod")}, strings.Clone("Running"))
+			col.Set(2, []string{strings.Clone("ns2")}, []string{strings.Clone("pod")}, strings.Clone("Running"))
+			col.Set(3, []string{strings.Clone("ns2")}, []string{strings.Clone("pod")}, strings.Clone("Running")) // existing series
+
+			first := col.GroupValues([]string{"ns1"}, "pod")
+			second := col.GroupValues([]string{"ns2"}, "pod")
+			require.Len(t, first, 1)
+			require.Len(t, second, 1)
+			assert.Same(t, unsafe.StringData(first[0].GroupValues[0]), unsafe.StringData(second[0].GroupValues[0]))
+			assert.Same(t, unsafe.StringData(first[0].ExtraValues[0]), unsafe.StringData(second[0].ExtraValues[0]))
+			assert.Equal(t, 3.0, second[0].Value)
+
+			expected := `
+# HELP testns_subsys_phase help text
+# TYPE testns_subsys_phase gauge
+testns_subsys_phase{namespace="ns1",phase="Running",pod="pod"} 1
+testns_subsys_phase{namespace="ns2",phase="Running",pod="pod"} 3
+`
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "testns_subsys_phase"))
+			assert.Equal(t, 2, col.DeleteByGroup([]string{"ns1"}, "pod")+col.DeleteByGroup([]string{"ns2"}, "pod"))
+		})
+	}
+}
+
+// Ensure values are not shared without interning
+func Test_DynamicGaugeCollector_LabelInterning_Disabled(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithStandaloneStorage(),
+	)
+
+	col.Set(1, []string{strings.Clone("ns1")}, []string{strings.Clone("pod")}, strings.Clone("Running"))
+	col.Set(2, []string{strings.Clone("ns2")}, []string{strings.Clone("pod")}, strings.Clone("Running"))
+
+	first := col.GroupValues([]string{"ns1"}, "pod")
+	second := col.GroupValues([]string{"ns2"}, "pod")
+	assert.NotSame(t, unsafe.StringData(first[0].GroupValues[0]), unsafe.StringData(second[0].GroupValues[0]))
+}
@@ -0,0 +1,102 @@
+package gauge_vec_set
+
+import (
+	"slices"
+)
+
+// Indexes returns the index label-values tuples of all tracked series, sorted.
+func (c *VecSet[T]) Indexes() [][]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	indexes := make([][]string, 0, len(c.indexes))
+	for indexKey := range c.indexes {
+		indexes = append(indexes, deserialize(indexKey))
+	}
+	slices.SortFunc(indexes, slices.Compare)
+	return indexes
+}
+
+// Groups returns the group label-values tuples tracked under the given index, sorted.
+func (c *VecSet[T]) Groups(indexValues ...string) [][]string {
+	groups, err := c.TryGroups(indexValues...)
+	if err != nil {
+		c.handleError(err)
+	}
+	return groups
+}
+
+// TryGroups is like Groups but returns an error instead of invoking the error handler.
+func (c *VecSet[T]) TryGroups(indexValues ...string) ([][]string, error) {
+	if err := c.validateIndexValues(indexValues); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	groupMap := c.indexes[serialize(indexValues)]
+	groups := make([][]string, 0, len(groupMap))
+	for groupKey := range groupMap {
+		groups = append(groups, deserialize(groupKey))
+	}
+	slices.SortFunc(groups, slices.Compare)
+	return groups, nil
+}
+
+// RangeFunc is called by Range for every tracked series. Returning false stops the iteration.
+type RangeFunc func(indexValues, groupValues, extraValues []string, value float64) bool
+
+// Range calls fn for every tracked series, in no particular order, until fn returns false.
+//
+// The read lock is held while fn runs, so fn must not modify the set; writers are blocked until Range returns.
+// Use RangeSnapshot if fn is slow or needs to modify the set.
+func (c *GaugeVecSet) Range(fn RangeFunc) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, groupMap := range c.indexes {
+		for _, group := range groupMap {
+			for _, s := range group {
+				indexValues, groupValues, extraValues := c.splitValues(slices.Clone(s.values))
+				if !fn(indexValues, groupValues, extraValues, gaugeValue(s.metric)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// RangeSnapshot is like Range but calls fn on a snapshot taken under the read lock,
+// so fn runs without holding any lock and may modify the set.
+func (c *GaugeVecSet) RangeSnapshot(fn RangeFunc) {
+	type entry struct {
+		values []string
+		value  float64
+	}
+
+	c.mu.RLock()
+	snapshot := make([]entry, 0, c.series)
+	for _, groupMap := range c.indexes {
+		for _, group := range groupMap {
+			for _, s := range group {
+				snapshot = append(snapshot, entry{values: s.values, value: gaugeValue(s.metric)})
+			}
+		}
+	}
+	c.mu.RUnlock()
+
+	for _, e := range snapshot {
+		indexValues, groupValues, extraValues := c.splitValues(slices.Clone(e.values))
+		if !fn(indexValues, groupValues, extraValues, e.value) {
+			return
+		}
+	}
+}
+
+// splitValues splits allValues into its index, group and extra parts.
+func (c *VecSet[T]) splitValues(allValues []string) (indexValues, groupValues, extraValues []string) {
+	i := len(c.indexLabels)
+	g := i + len(c.groupLabels)
+	return allValues[:i:i], allValues[i:g:g], allValues[g:]
+}
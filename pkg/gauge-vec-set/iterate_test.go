@@ -0,0 +1,74 @@
+package gauge_vec_set
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rangedSeries struct {
+	index, group, extra []string
+	value               float64
+}
+
+func collectRange(rangeFn func(RangeFunc)) []rangedSeries {
+	var out []rangedSeries
+	rangeFn(func(index, group, extra []string, value float64) bool {
+		out = append(out, rangedSeries{index: index, group: group, extra: extra, value: value})
+		return true
+	})
+	slices.SortFunc(out, func(a, b rangedSeries) int {
+		return slices.Compare(buildAllValues(a.index, a.group, a.extra), buildAllValues(b.index, b.group, b.extra))
+	})
+	return out
+}
+
+// Ensure everything tracked by the set can be enumerated
+func Test_DynamicGaugeCollector_Enumeration(t *testing.T) {
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"conditions_range",
+		"help text",
+		[]string{"controller", "name"}, // index
+		[]string{"condition"},          // group
+		"status",                       // extra
+	)
+
+	col.SetActiveInGroup(1, []string{"ctrl", "b"}, []string{"Ready"}, "True")
+	col.SetActiveInGroup(1, []string{"ctrl", "b"}, []string{"Ready"}, "False")
+	col.Set(3, []string{"ctrl", "b"}, []string{"Synced"}, "True")
+	col.Set(2, []string{"ctrl", "a"}, []string{"Ready"}, "True")
+
+	assert.Equal(t, [][]string{{"ctrl", "a"}, {"ctrl", "b"}}, col.Indexes())
+	assert.Equal(t, [][]string{{"Ready"}, {"Synced"}}, col.Groups("ctrl", "b"))
+	assert.Empty(t, col.Groups("ctrl", "missing"))
+
+	want := []rangedSeries{
+		{index: []string{"ctrl", "a"}, group: []string{"Ready"}, extra: []string{"True"}, value: 2},
+		{index: []string{"ctrl", "b"}, group: []string{"Ready"}, extra: []string{"False"}, value: 1},
+		{index: []string{"ctrl", "b"}, group: []string{"Ready"}, extra: []string{"True"}, value: 0},
+		{index: []string{"ctrl", "b"}, group: []string{"Synced"}, extra: []string{"True"}, value: 3},
+	}
+	assert.Equal(t, want, collectRange(col.Range))
+	assert.Equal(t, want, collectRange(col.RangeSnapshot))
+
+	// Returning false stops the iteration
+	calls := 0
+	col.Range(func(_, _, _ []string, _ float64) bool {
+		calls++
+		return false
+	})
+	assert.Equal(t, 1, calls)
+
+	// The snapshot callback may modify the set
+	col.RangeSnapshot(func(index, _, _ []string, _ float64) bool {
+		col.DeleteByIndex(index...)
+		return true
+	})
+	assert.Empty(t, col.Indexes())
+
+	_, err := col.TryGroups("ctrl")
+	assert.ErrorIs(t, err, ErrIndexArity)
+}
@@ -0,0 +1,55 @@
+package gauge_vec_set
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Label values are encoded into a single string key by prefixing every value with its length in bytes
+// followed by this character, e.g. {"ab", "c"} -> "2:ab1:c".
+//
+// The encoding is lossless: values may contain any character (including the separator), distinct tuples never
+// share a key, and deserialize(serialize(v)) == v. It is also concatenative, so
+// serialize(a + b) == serialize(a) + serialize(b).
+const labelLengthSeparator = ':'
+
+// buildAllValues concatenates values in the canonical order: index + group + extra.
+func buildAllValues(indexValues, groupValues, extraValues []string) []string {
+	allVals := make([]string, 0, len(indexValues)+len(groupValues)+len(extraValues))
+	allVals = append(allVals, indexValues...)
+	allVals = append(allVals, groupValues...)
+	allVals = append(allVals, extraValues...)
+	return allVals
+}
+
+// serialize encodes label values into a single length-prefixed key.
+func serialize(labelValues []string) string {
+	size := 0
+	for _, v := range labelValues {
+		// Length prefixes rarely exceed 3 digits.
+		size += len(v) + 4
+	}
+
+	var b strings.Builder
+	b.Grow(size)
+	for _, v := range labelValues {
+		b.WriteString(strconv.Itoa(len(v)))
+		b.WriteByte(labelLengthSeparator)
+		b.WriteString(v)
+	}
+	return b.String()
+}
+
+// deserialize decodes a key produced by serialize back into label values.
+// The returned values share memory with s.
+func deserialize(s string) []string {
+	var values []string
+	for len(s) > 0 {
+		sep := strings.IndexByte(s, labelLengthSeparator)
+		n, _ := strconv.Atoi(s[:sep])
+		s = s[sep+1:]
+		values = append(values, s[:n])
+		s = s[n:]
+	}
+	return values
+}
@@ -0,0 +1,137 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// splitLabels maps the named labels onto the given label name lists, returning one values slice per list
+// in the configured order. Every name in the lists must be present in labels, and labels must not contain
+// any other name.
+func splitLabels(labels prometheus.Labels, labelLists ...[]string) ([][]string, error) {
+	expected := 0
+	out := make([][]string, len(labelLists))
+	for i, names := range labelLists {
+		values := make([]string, len(names))
+		for j, name := range names {
+			v, ok := labels[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrMissingLabel, name)
+			}
+			values[j] = v
+		}
+		out[i] = values
+		expected += len(names)
+	}
+
+	if len(labels) != expected {
+		return nil, unknownLabelsError(labels, labelLists)
+	}
+	return out, nil
+}
+
+// unknownLabelsError reports the labels that are not part of any of the given label name lists.
+func unknownLabelsError(labels prometheus.Labels, labelLists [][]string) error {
+	known := make(map[string]struct{})
+	for _, names := range labelLists {
+		for _, name := range names {
+			known[name] = struct{}{}
+		}
+	}
+	var unknown []string
+	for name := range labels {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("%w: %q", ErrUnknownLabel, unknown)
+}
+
+// SetWith is like Set but takes the label values by name.
+func (c *GaugeVecSet) SetWith(value float64, labels prometheus.Labels) {
+	if err := c.TrySetWith(value, labels); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySetWith is like SetWith but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TrySetWith(value float64, labels prometheus.Labels) error {
+	values, err := splitLabels(labels, c.indexLabels, c.groupLabels, c.extraLabels)
+	if err != nil {
+		return err
+	}
+	return c.TrySet(value, values[0], values[1], values[2]...)
+}
+
+// SetActiveInGroupWith is like SetActiveInGroup but takes the label values by name.
+func (c *GaugeVecSet) SetActiveInGroupWith(value float64, labels prometheus.Labels) {
+	if err := c.TrySetActiveInGroupWith(value, labels); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySetActiveInGroupWith is like SetActiveInGroupWith but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TrySetActiveInGroupWith(value float64, labels prometheus.Labels) error {
+	values, err := splitLabels(labels, c.indexLabels, c.groupLabels, c.extraLabels)
+	if err != nil {
+		return err
+	}
+	return c.TrySetActiveInGroup(value, values[0], values[1], values[2]...)
+}
+
+// SetGroupWith is like SetGroup but takes the label values by name.
+func (c *GaugeVecSet) SetGroupWith(value float64, labels prometheus.Labels) {
+	if err := c.TrySetGroupWith(value, labels); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySetGroupWith is like SetGroupWith but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TrySetGroupWith(value float64, labels prometheus.Labels) error {
+	values, err := splitLabels(labels, c.indexLabels, c.groupLabels, c.extraLabels)
+	if err != nil {
+		return err
+	}
+	return c.TrySetGroup(value, values[0], values[1], values[2]...)
+}
+
+// DeleteByIndexWith is like DeleteByIndex but takes the index label values by name.
+// labels must contain exactly the configured index labels.
+func (c *VecSet[T]) DeleteByIndexWith(labels prometheus.Labels) (deleted int) {
+	deleted, err := c.TryDeleteByIndexWith(labels)
+	if err != nil {
+		c.handleError(err)
+	}
+	return deleted
+}
+
+// TryDeleteByIndexWith is like DeleteByIndexWith but returns an error instead of invoking the error handler.
+func (c *VecSet[T]) TryDeleteByIndexWith(labels prometheus.Labels) (deleted int, err error) {
+	values, err := splitLabels(labels, c.indexLabels)
+	if err != nil {
+		return 0, err
+	}
+	return c.TryDeleteByIndex(values[0]...)
+}
+
+// DeleteByGroupWith is like DeleteByGroup but takes the label values by name.
+// labels must contain exactly the configured index and group labels.
+func (c *VecSet[T]) DeleteByGroupWith(labels prometheus.Labels) (deleted int) {
+	deleted, err := c.TryDeleteByGroupWith(labels)
+	if err != nil {
+		c.handleError(err)
+	}
+	return deleted
+}
+
+// TryDeleteByGroupWith is like DeleteByGroupWith but returns an error instead of invoking the error handler.
+func (c *VecSet[T]) TryDeleteByGroupWith(labels prometheus.Labels) (deleted int, err error) {
+	values, err := splitLabels(labels, c.indexLabels, c.groupLabels)
+	if err != nil {
+		return 0, err
+	}
+	return c.TryDeleteByGroup(values[0], values[1]...)
+}
@@ -0,0 +1,95 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure the label map API maps named labels onto index, group and extra labels regardless of map order
+func Test_DynamicGaugeCollector_LabelMapAPI(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"conditions_with",
+		"help text",
+		[]string{"controller", "namespace"}, // index
+		[]string{"condition"},               // group
+		"status", "reason",                  // extra
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.SetActiveInGroupWith(1, prometheus.Labels{
+		"namespace": "ns", "controller": "ctrl", "condition": "Ready", "status": "True", "reason": "",
+	})
+	col.SetActiveInGroupWith(1, prometheus.Labels{
+		"reason": "bad_secret", "status": "False", "condition": "Ready", "namespace": "ns", "controller": "ctrl",
+	})
+	col.SetWith(1, prometheus.Labels{
+		"controller": "ctrl", "namespace": "ns", "condition": "Synchronized", "status": "True", "reason": "",
+	})
+	col.SetGroupWith(1, prometheus.Labels{
+		"controller": "ctrl", "namespace": "other", "condition": "Ready", "status": "True", "reason": "",
+	})
+
+	want := `
+# HELP testns_subsys_conditions_with help text
+# TYPE testns_subsys_conditions_with gauge
+testns_subsys_conditions_with{condition="Ready",controller="ctrl",namespace="ns",reason="",status="True"} 0
+testns_subsys_conditions_with{condition="Ready",controller="ctrl",namespace="ns",reason="bad_secret",status="False"} 1
+testns_subsys_conditions_with{condition="Ready",controller="ctrl",namespace="other",reason="",status="True"} 1
+testns_subsys_conditions_with{condition="Synchronized",controller="ctrl",namespace="ns",reason="",status="True"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_conditions_with"))
+
+	assert.Equal(t, 2, col.DeleteByGroupWith(prometheus.Labels{
+		"controller": "ctrl", "namespace": "ns", "condition": "Ready",
+	}))
+	assert.Equal(t, 1, col.DeleteByIndexWith(prometheus.Labels{"controller": "ctrl", "namespace": "ns"}))
+
+	want = `
+# HELP testns_subsys_conditions_with help text
+# TYPE testns_subsys_conditions_with gauge
+testns_subsys_conditions_with{condition="Ready",controller="ctrl",namespace="other",reason="",status="True"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_conditions_with"))
+}
+
+func Test_DynamicGaugeCollector_LabelMapAPIErrors(t *testing.T) {
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"conditions_with_errors",
+		"help text",
+		[]string{"controller"}, // index
+		[]string{"condition"},  // group
+		"status",               // extra
+	)
+
+	err := col.TrySetWith(1, prometheus.Labels{"controller": "ctrl", "condition": "Ready"})
+	assert.ErrorIs(t, err, ErrMissingLabel)
+	assert.ErrorContains(t, err, `"status"`)
+
+	err = col.TrySetGroupWith(1, prometheus.Labels{
+		"controller": "ctrl", "condition": "Ready", "status": "True", "bogus": "x",
+	})
+	assert.ErrorIs(t, err, ErrUnknownLabel)
+	assert.ErrorContains(t, err, `"bogus"`)
+
+	// Group deletion only accepts index and group labels
+	_, err = col.TryDeleteByGroupWith(prometheus.Labels{"controller": "ctrl", "condition": "Ready", "status": "True"})
+	assert.ErrorIs(t, err, ErrUnknownLabel)
+
+	_, err = col.TryDeleteByIndexWith(prometheus.Labels{})
+	assert.ErrorIs(t, err, ErrMissingLabel)
+
+	assert.Panics(t, func() {
+		col.SetActiveInGroupWith(1, prometheus.Labels{"controller": "ctrl"})
+	})
+}
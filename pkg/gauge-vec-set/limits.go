@@ -0,0 +1,270 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+)
+
+// OverflowLabelValue is the label value of the sentinel series that OverflowFold redirects writes to.
+const OverflowLabelValue = "__overflow__"
+
+// OverflowPolicy decides what happens to a write that would create a series beyond one of the Limits.
+// Writes to series that already exist are never affected.
+type OverflowPolicy int
+
+const (
+	// OverflowReject drops the write and reports ErrCardinalityLimit.
+	OverflowReject OverflowPolicy = iota
+	// OverflowEvict deletes the least-recently-updated series to make room for the new one:
+	//   - the least-recently-updated index when MaxSeries is reached.
+	//   - the least-recently-updated group of the index when MaxSeriesPerIndex is reached.
+	//   - the least-recently-updated series of the group when MaxSeriesPerGroup is reached.
+	OverflowEvict
+	// OverflowFold redirects the write to a sentinel series, whose label values outside the full scope are
+	// replaced with OverflowLabelValue:
+	//   - all label values when MaxSeries is reached.
+	//   - the group and extra values when MaxSeriesPerIndex is reached.
+	//   - the extra values when MaxSeriesPerGroup is reached.
+	//
+	// Sentinel series are not subject to the limits themselves.
+	OverflowFold
+)
+
+// Limits caps the cardinality of a set. A non-positive limit is disabled.
+type Limits struct {
+	MaxSeries         int // total number of series
+	MaxSeriesPerIndex int // number of series per index label-values tuple
+	MaxSeriesPerGroup int // number of series per (index, group) label-values tuple
+	Policy            OverflowPolicy
+}
+
+// enabled reports whether any limit is set.
+func (l Limits) enabled() bool {
+	return l.MaxSeries > 0 || l.MaxSeriesPerIndex > 0 || l.MaxSeriesPerGroup > 0
+}
+
+// LimitStats counts the writes affected by the Limits of a set since it was created.
+type LimitStats struct {
+	Rejected uint64 // writes dropped by OverflowReject
+	Evicted  uint64 // series deleted by OverflowEvict
+	Folded   uint64 // writes redirected to a sentinel series by OverflowFold
+}
+
+// limitScope identifies the limit a write would exceed.
+type limitScope int
+
+const (
+	scopeNone limitScope = iota
+	scopeGroup
+	scopeIndex
+	scopeTotal
+)
+
+// SetLimits replaces the cardinality limits of the set. Series written before are kept, even if they exceed the
+// new limits; the limits only apply to writes that create a series. Call this before the set is used concurrently.
+func (c *VecSet[T]) SetLimits(limits Limits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.limits = limits
+	if limits.enabled() && limits.Policy == OverflowEvict {
+		c.indexTicks = make(map[string]uint64, len(c.indexes))
+		c.seriesTicks = make(map[string]uint64, c.series)
+	} else {
+		c.indexTicks = nil
+		c.seriesTicks = nil
+	}
+}
+
+// LimitStats returns the number of writes rejected or folded and of series evicted by the set's limits.
+func (c *VecSet[T]) LimitStats() LimitStats {
+	return LimitStats{
+		Rejected: c.rejected.Load(),
+		Evicted:  c.evicted.Load(),
+		Folded:   c.folded.Load(),
+	}
+}
+
+// admit checks the limits for a write to the series identified by allValues and returns the series the write
+// must go to. Writes beyond a limit are rejected with ErrCardinalityLimit, make room by evicting other series or
+// are folded into a sentinel series, depending on the policy.
+//
+// The check and the write are not atomic: concurrent writers creating series may exceed a limit by at most one
+// series each. Use admitLocked to check and write under the same lock.
+func (c *VecSet[T]) admit(indexKey, groupKey string, allValues []string) (seriesRef, error) {
+	if !c.limits.enabled() {
+		return seriesRef{indexKey: indexKey, groupKey: groupKey, fullKey: serialize(allValues), allValues: allValues}, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.admitLocked(indexKey, groupKey, allValues)
+}
+
+// admitLocked is admit for callers already holding the write lock.
+func (c *VecSet[T]) admitLocked(indexKey, groupKey string, allValues []string) (seriesRef, error) {
+	ref := seriesRef{indexKey: indexKey, groupKey: groupKey, fullKey: serialize(allValues), allValues: allValues}
+	if !c.limits.enabled() {
+		return ref, nil
+	}
+	scope := c.exceededLocked(ref)
+	if scope == scopeNone {
+		return ref, nil
+	}
+
+	switch c.limits.Policy {
+	case OverflowEvict:
+		var evicted [][]string
+		for ; scope != scopeNone; scope = c.exceededLocked(ref) {
+			evicted = append(evicted, c.evictLocked(ref, scope)...)
+		}
+		c.evicted.Add(uint64(len(evicted)))
+		c.deleteSeries(evicted)
+		return ref, nil
+	case OverflowFold:
+		c.folded.Add(1)
+		return c.overflowRef(ref, scope), nil
+	default:
+		c.rejected.Add(1)
+		return ref, c.limitError(scope)
+	}
+}
+
+// admitWithoutEviction is admit for writes that must not evict other series, such as the zero-valued states
+// materialized next to the active state of an enum, since the least-recently-updated series may be the one the same
+// call just activated. With OverflowEvict, ok is false if ref exceeds a limit; with the other policies, ok is false
+// if admit returns an error.
+func (c *VecSet[T]) admitWithoutEviction(indexKey, groupKey string, allValues []string) (ref seriesRef, ok bool) {
+	if !c.limits.enabled() || c.limits.Policy != OverflowEvict {
+		ref, err := c.admit(indexKey, groupKey, allValues)
+		return ref, err == nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ref = seriesRef{indexKey: indexKey, groupKey: groupKey, fullKey: serialize(allValues), allValues: allValues}
+	return ref, c.exceededLocked(ref) == scopeNone
+}
+
+// exceededLocked returns the narrowest limit that writing ref would exceed, or scopeNone if ref already exists or
+// fits within all limits. The caller must hold the write lock.
+func (c *VecSet[T]) exceededLocked(ref seriesRef) limitScope {
+	groupMap := c.indexes[ref.indexKey]
+	if _, exists := groupMap[ref.groupKey][ref.fullKey]; exists {
+		return scopeNone
+	}
+	if limit := c.limits.MaxSeriesPerGroup; limit > 0 && len(groupMap[ref.groupKey]) >= limit {
+		return scopeGroup
+	}
+	if limit := c.limits.MaxSeriesPerIndex; limit > 0 {
+		var n int
+		for _, group := range groupMap {
+			n += len(group)
+		}
+		if n >= limit {
+			return scopeIndex
+		}
+	}
+	if limit := c.limits.MaxSeries; limit > 0 && c.series >= limit {
+		return scopeTotal
+	}
+	return scopeNone
+}
+
+// evictLocked detaches the least-recently-updated series, group or index within scope of ref and returns the
+// label values of the detached series. The caller must hold the write lock and delete them from the metric vector.
+func (c *VecSet[T]) evictLocked(ref seriesRef, scope limitScope) [][]string {
+	switch scope {
+	case scopeGroup:
+		oldest := ""
+		for hash := range c.indexes[ref.indexKey][ref.groupKey] {
+			if oldest == "" || c.seriesTicks[hash] < c.seriesTicks[oldest] {
+				oldest = hash
+			}
+		}
+		s, _ := c.uncacheLocked(ref.indexKey, ref.groupKey, oldest)
+		return [][]string{s.values}
+	case scopeIndex:
+		oldest, oldestTick := "", uint64(0)
+		for groupKey, group := range c.indexes[ref.indexKey] {
+			var tick uint64
+			for hash := range group {
+				tick = max(tick, c.seriesTicks[hash])
+			}
+			if oldest == "" || tick < oldestTick {
+				oldest, oldestTick = groupKey, tick
+			}
+		}
+		return c.detachGroupLocked(ref.indexKey, oldest)
+	default:
+		oldest := ""
+		for indexKey := range c.indexes {
+			if oldest == "" || c.indexTicks[indexKey] < c.indexTicks[oldest] {
+				oldest = indexKey
+			}
+		}
+		return c.detachIndexLocked(oldest)
+	}
+}
+
+// overflowRef returns the sentinel series that a write to ref exceeding the limit of scope is folded into.
+func (c *VecSet[T]) overflowRef(ref seriesRef, scope limitScope) seriesRef {
+	keep := 0
+	switch scope {
+	case scopeGroup:
+		keep = len(c.indexLabels) + len(c.groupLabels)
+	case scopeIndex:
+		keep = len(c.indexLabels)
+	}
+
+	values := make([]string, len(ref.allValues))
+	copy(values, ref.allValues[:keep])
+	for i := keep; i < len(values); i++ {
+		values[i] = OverflowLabelValue
+	}
+	indexValues, groupValues, _ := c.splitValues(values)
+	return seriesRef{
+		indexKey:  serialize(indexValues),
+		groupKey:  serialize(groupValues),
+		fullKey:   serialize(values),
+		allValues: values,
+		folded:    true,
+	}
+}
+
+// isOverflow reports whether allValues are those of a sentinel series of OverflowFold: the values from the start of
+// the index, group or extra labels on are all OverflowLabelValue.
+func (c *VecSet[T]) isOverflow(allValues []string) bool {
+	if !c.limits.enabled() || c.limits.Policy != OverflowFold {
+		return false
+	}
+	start := len(allValues)
+	for start > 0 && allValues[start-1] == OverflowLabelValue {
+		start--
+	}
+	groupStart := len(c.indexLabels)
+	extraStart := groupStart + len(c.groupLabels)
+	return start < len(allValues) && (start == 0 || start == groupStart || start == extraStart)
+}
+
+// limitError describes the limit of scope for OverflowReject.
+func (c *VecSet[T]) limitError(scope limitScope) error {
+	switch scope {
+	case scopeGroup:
+		return fmt.Errorf("%w: %d series per group", ErrCardinalityLimit, c.limits.MaxSeriesPerGroup)
+	case scopeIndex:
+		return fmt.Errorf("%w: %d series per index", ErrCardinalityLimit, c.limits.MaxSeriesPerIndex)
+	default:
+		return fmt.Errorf("%w: %d series", ErrCardinalityLimit, c.limits.MaxSeries)
+	}
+}
+
+// touchLocked advances the recency of ref's index and series, if eviction is enabled.
+// The caller must hold the write lock.
+func (c *VecSet[T]) touchLocked(indexKey, fullKey string) {
+	if c.seriesTicks == nil {
+		return
+	}
+	c.tick++
+	c.indexTicks[indexKey] = c.tick
+	c.seriesTicks[fullKey] = c.tick
+}
@@ -0,0 +1,212 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure writes beyond each limit are rejected with ErrCardinalityLimit while existing series stay writable
+func Test_DynamicGaugeCollector_Limits_Reject(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "limited", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithLimits(Limits{MaxSeries: 4, MaxSeriesPerIndex: 3, MaxSeriesPerGroup: 2}),
+	)
+
+	require.NoError(t, col.TrySet(1, []string{"ns1"}, []string{"a"}, "Running"))
+	require.NoError(t, col.TrySet(1, []string{"ns1"}, []string{"a"}, "Pending"))
+
+	err := col.TrySet(1, []string{"ns1"}, []string{"a"}, "Failed")
+	require.ErrorIs(t, err, ErrCardinalityLimit)
+	assert.Contains(t, err.Error(), "2 series per group")
+
+	require.NoError(t, col.TrySet(1, []string{"ns1"}, []string{"b"}, "Running"))
+	err = col.TrySet(1, []string{"ns1"}, []string{"c"}, "Running")
+	require.ErrorIs(t, err, ErrCardinalityLimit)
+	assert.Contains(t, err.Error(), "3 series per index")
+
+	require.NoError(t, col.TrySet(1, []string{"ns2"}, []string{"a"}, "Running"))
+	err = col.TrySet(1, []string{"ns3"}, []string{"a"}, "Running")
+	require.ErrorIs(t, err, ErrCardinalityLimit)
+	assert.Contains(t, err.Error(), "4 series")
+
+	// Existing series are always writable
+	require.NoError(t, col.TrySet(5, []string{"ns1"}, []string{"a"}, "Running"))
+
+	assert.Equal(t, 4, testutil.CollectAndCount(col))
+	assert.Equal(t, LimitStats{Rejected: 3}, col.LimitStats())
+
+	// Deletions make room again
+	col.DeleteByIndex("ns2")
+	require.NoError(t, col.TrySet(1, []string{"ns3"}, []string{"a"}, "Running"))
+}
+
+// Ensure the non-Try operations route rejections through the error handler
+func Test_DynamicGaugeCollector_Limits_RejectErrorHandler(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "limited", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithLimits(Limits{MaxSeries: 1}),
+	)
+	var errs []error
+	col.SetErrorHandler(func(err error) { errs = append(errs, err) })
+
+	col.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+	col.Add(1, []string{"ns2"}, []string{"a"}, "Running")
+	col.BindGroup([]string{"ns3"}, "a").SetGroup(1, "Running")
+	col.SetActiveInGroup(1, []string{"ns4"}, []string{"a"}, "Running")
+
+	require.Len(t, errs, 3)
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrCardinalityLimit)
+	}
+	assert.Equal(t, 1, testutil.CollectAndCount(col))
+}
+
+// Ensure OverflowEvict deletes the least-recently-updated series, group or index to make room
+func Test_DynamicGaugeCollector_Limits_Evict(t *testing.T) {
+	t.Run("per group", func(t *testing.T) {
+		col := NewGaugeVecSetWithOpts(
+			prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "limited", Help: "help text"},
+			[]string{"namespace"}, // index
+			[]string{"pod"},       // group
+			[]string{"phase"},     // extra
+			WithLimits(Limits{MaxSeriesPerGroup: 2, Policy: OverflowEvict}),
+		)
+
+		col.Set(1, []string{"ns1"}, []string{"a"}, "Pending")
+		col.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+		col.Set(2, []string{"ns1"}, []string{"a"}, "Pending") // Running is now the least recently updated
+		col.Set(1, []string{"ns1"}, []string{"a"}, "Failed")
+
+		series, err := col.TryGroupValues([]string{"ns1"}, "a")
+		require.NoError(t, err)
+		require.Len(t, series, 2)
+		assert.Equal(t, []string{"Failed"}, series[0].ExtraValues)
+		assert.Equal(t, []string{"Pending"}, series[1].ExtraValues)
+		assert.Equal(t, LimitStats{Evicted: 1}, col.LimitStats())
+	})
+
+	t.Run("per index", func(t *testing.T) {
+		col := NewGaugeVecSetWithOpts(
+			prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "limited", Help: "help text"},
+			[]string{"namespace"}, // index
+			[]string{"pod"},       // group
+			[]string{"phase"},     // extra
+			WithLimits(Limits{MaxSeriesPerIndex: 3, Policy: OverflowEvict}),
+		)
+
+		col.Set(1, []string{"ns1"}, []string{"a"}, "Pending")
+		col.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+		col.Set(1, []string{"ns1"}, []string{"b"}, "Running")
+		col.Set(1, []string{"ns1"}, []string{"c"}, "Running") // evicts group a
+
+		groups, err := col.TryGroups("ns1")
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"b"}, {"c"}}, groups)
+		assert.Equal(t, LimitStats{Evicted: 2}, col.LimitStats())
+	})
+
+	t.Run("total", func(t *testing.T) {
+		col := NewGaugeVecSetWithOpts(
+			prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "limited", Help: "help text"},
+			[]string{"namespace"}, // index
+			[]string{"pod"},       // group
+			[]string{"phase"},     // extra
+			WithLimits(Limits{MaxSeries: 2, Policy: OverflowEvict}),
+		)
+
+		col.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+		col.Set(1, []string{"ns2"}, []string{"a"}, "Running")
+		col.Set(1, []string{"ns1"}, []string{"a"}, "Running") // ns2 is now the least recently updated
+		col.Set(1, []string{"ns3"}, []string{"a"}, "Running")
+
+		assert.Equal(t, [][]string{{"ns1"}, {"ns3"}}, col.Indexes())
+		assert.Equal(t, 2, testutil.CollectAndCount(col))
+		assert.Equal(t, LimitStats{Evicted: 1}, col.LimitStats())
+	})
+}
+
+// Ensure OverflowFold redirects writes beyond a limit into sentinel series
+func Test_DynamicGaugeCollector_Limits_Fold(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "limited", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithLimits(Limits{MaxSeries: 3, MaxSeriesPerIndex: 2, MaxSeriesPerGroup: 1, Policy: OverflowFold}),
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+	col.Set(2, []string{"ns1"}, []string{"a"}, "Pending") // group full
+	col.Add(3, []string{"ns1"}, []string{"b"}, "Running") // index full (a + a/__overflow__)
+	col.Add(4, []string{"ns1"}, []string{"c"}, "Running") // index full, accumulates
+	col.Set(5, []string{"ns2"}, []string{"a"}, "Running") // total full
+
+	expected := `
+# HELP testns_subsys_limited help text
+# TYPE testns_subsys_limited gauge
+testns_subsys_limited{namespace="__overflow__",phase="__overflow__",pod="__overflow__"} 5
+testns_subsys_limited{namespace="ns1",phase="Running",pod="a"} 1
+testns_subsys_limited{namespace="ns1",phase="__overflow__",pod="__overflow__"} 7
+testns_subsys_limited{namespace="ns1",phase="__overflow__",pod="a"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "testns_subsys_limited"))
+	assert.Equal(t, LimitStats{Folded: 4}, col.LimitStats())
+
+	// Sentinel series are indexed like any other series
+	assert.Equal(t, 3, col.DeleteByIndex("ns1"))
+	assert.Equal(t, 1, col.DeleteByIndex(OverflowLabelValue))
+}
+
+// Ensure SetActiveInGroup only materializes the enum states the limits admit
+func Test_DynamicGaugeCollector_Limits_Enum(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "limited", Help: "help text"},
+		[]string{"namespace"}, []string{"pod"}, []string{"phase"},
+		WithEnum("phase", "Pending", "Running", "Failed"),
+		WithLimits(Limits{MaxSeriesPerGroup: 2}),
+	)
+
+	require.NoError(t, col.TrySetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Running"))
+	assert.Equal(t, 2, testutil.CollectAndCount(col))
+	assert.Equal(t, LimitStats{Rejected: 1}, col.LimitStats())
+}
+
+// Ensure materializing the enum states never evicts the state SetActiveInGroup just activated
+func Test_DynamicGaugeCollector_Limits_EnumEvict(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "limited", Help: "help text"},
+		[]string{"namespace"}, []string{"pod"}, []string{"phase"},
+		WithEnum("phase", "A", "B", "C", "D"),
+		WithLimits(Limits{MaxSeriesPerGroup: 2, Policy: OverflowEvict}),
+	)
+
+	require.NoError(t, col.TrySetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "A"))
+	value, ok := col.Get([]string{"ns1"}, []string{"a"}, "A")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, value)
+	extra, _, ok := col.ActiveInGroup([]string{"ns1"}, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"A"}, extra)
+	assert.Equal(t, 2, testutil.CollectAndCount(col))
+	assert.Equal(t, LimitStats{}, col.LimitStats())
+
+	// The next transition evicts the oldest state to make room for the new active one, and keeps it.
+	require.NoError(t, col.TrySetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "D"))
+	extra, _, ok = col.ActiveInGroup([]string{"ns1"}, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"D"}, extra)
+	assert.Equal(t, 2, testutil.CollectAndCount(col))
+}
@@ -0,0 +1,167 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// MatchType is the comparison a LabelMatcher applies to a label value.
+type MatchType int
+
+const (
+	// MatchEqual selects the value equal to LabelMatcher.Value.
+	MatchEqual MatchType = iota
+	// MatchNotEqual selects every value but LabelMatcher.Value.
+	MatchNotEqual
+	// MatchRegexp selects the values fully matched by the regular expression LabelMatcher.Value.
+	MatchRegexp
+	// MatchNotRegexp selects the values not fully matched by the regular expression LabelMatcher.Value.
+	MatchNotRegexp
+)
+
+// LabelMatcher selects series by the value of one of the index, group or extra labels of a set,
+// like a PromQL label matcher.
+type LabelMatcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+
+	re *regexp.Regexp
+}
+
+// NewLabelMatcher returns a matcher for the label name. Regular expressions are anchored at both ends, like in
+// PromQL, so MatchRegexp "tmp-.*" selects "tmp-1" but not "x-tmp-1".
+func NewLabelMatcher(t MatchType, name, value string) (LabelMatcher, error) {
+	m := LabelMatcher{Type: t, Name: name, Value: value}
+	if t == MatchRegexp || t == MatchNotRegexp {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return LabelMatcher{}, fmt.Errorf("label matcher %q: %w", name, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// MustNewLabelMatcher is like NewLabelMatcher but panics if the regular expression does not compile.
+func MustNewLabelMatcher(t MatchType, name, value string) LabelMatcher {
+	m, err := NewLabelMatcher(t, name, value)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Matches reports whether value is selected by the matcher.
+func (m LabelMatcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	default:
+		return value == m.Value
+	}
+}
+
+// DeleteMatching removes all series selected by every matcher; without matchers, it removes every series.
+// Matchers on index and group labels skip whole indexes and groups without visiting their series.
+// Returns the number of deleted series.
+//
+// Example:
+//
+//	col.DeleteMatching(
+//		MustNewLabelMatcher(MatchRegexp, "namespace", "tmp-.*"),
+//		MustNewLabelMatcher(MatchEqual, "reason", "Deprecated"),
+//	)
+func (c *VecSet[T]) DeleteMatching(matchers ...LabelMatcher) (deleted int) {
+	deleted, err := c.TryDeleteMatching(matchers...)
+	if err != nil {
+		c.handleError(err)
+	}
+	return deleted
+}
+
+// TryDeleteMatching is like DeleteMatching but returns an error instead of invoking the error handler.
+// Matchers on labels the set does not have are rejected with ErrUnknownLabel.
+func (c *VecSet[T]) TryDeleteMatching(matchers ...LabelMatcher) (deleted int, err error) {
+	allLabels := buildAllValues(c.indexLabels, c.groupLabels, c.extraLabels)
+
+	// Matchers by the position of their label in allLabels.
+	byPosition := make([][]LabelMatcher, len(allLabels))
+	for _, m := range matchers {
+		position := slices.Index(allLabels, m.Name)
+		if position < 0 {
+			return 0, fmt.Errorf("%w: %q", ErrUnknownLabel, m.Name)
+		}
+		if m.re == nil && (m.Type == MatchRegexp || m.Type == MatchNotRegexp) {
+			if m, err = NewLabelMatcher(m.Type, m.Name, m.Value); err != nil {
+				return 0, err
+			}
+		}
+		byPosition[position] = append(byPosition[position], m)
+	}
+
+	matchValue := func(position int, value string) bool {
+		for _, m := range byPosition[position] {
+			if !m.Matches(value) {
+				return false
+			}
+		}
+		return true
+	}
+	return c.deleteSelected(matchValue, nil), nil
+}
+
+// DeleteWhere removes all series for which fn returns true. Returns the number of deleted series.
+//
+// fn is called with the write lock held, so it must not use the set, and must not modify or retain the slices.
+func (c *VecSet[T]) DeleteWhere(fn func(indexValues, groupValues, extraValues []string) bool) (deleted int) {
+	return c.deleteSelected(nil, func(allValues []string) bool {
+		return fn(c.splitValues(allValues))
+	})
+}
+
+// deleteSelected removes the series selected by matchValue, which is called with the position of a label in
+// index + group + extra and its value, and by matchSeries, which is called with all label values.
+// Either may be nil to select everything. Index and group values are checked before visiting the series below.
+func (c *VecSet[T]) deleteSelected(
+	matchValue func(position int, value string) bool, matchSeries func(allValues []string) bool,
+) (deleted int) {
+	c.mu.Lock()
+	var selected [][]string
+	groupOffset, extraOffset := len(c.indexLabels), len(c.indexLabels)+len(c.groupLabels)
+	for _, indexKey := range c.indexTrie.collectMatching(matchValue) {
+		for groupKey, group := range c.indexes[indexKey] {
+			if matchValue != nil && !matchesFrom(matchValue, groupOffset, deserialize(groupKey)) {
+				continue
+			}
+			for fullKey, s := range group {
+				if matchValue != nil && !matchesFrom(matchValue, extraOffset, s.values[extraOffset:]) {
+					continue
+				}
+				if matchSeries != nil && !matchSeries(s.values) {
+					continue
+				}
+				c.uncacheLocked(indexKey, groupKey, fullKey)
+				selected = append(selected, s.values)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	return c.deleteSeries(selected)
+}
+
+// matchesFrom reports whether every value matches, values[i] being the label at position offset+i.
+func matchesFrom(matchValue func(position int, value string) bool, offset int, values []string) bool {
+	for i, value := range values {
+		if !matchValue(offset+i, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure DeleteMatching removes the series selected by all matchers, on any label
+func Test_DynamicGaugeCollector_DeleteMatching(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewGaugeVecSet("testns", "subsys", "conditions", "help text",
+		[]string{"namespace", "name"}, // index
+		[]string{"condition"},         // group
+		"status", "reason",            // extra
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, []string{"tmp-1", "a"}, []string{"Ready"}, "False", "Deprecated")
+	col.Set(1, []string{"tmp-1", "a"}, []string{"Synced"}, "True", "Ok")
+	col.Set(1, []string{"tmp-2", "b"}, []string{"Ready"}, "True", "Ok")
+	col.Set(1, []string{"prod", "a"}, []string{"Ready"}, "False", "Deprecated")
+	col.Set(1, []string{"prod", "b"}, []string{"Synced"}, "False", "Failed")
+
+	// extra label
+	assert.Equal(t, 2, col.DeleteMatching(MustNewLabelMatcher(MatchEqual, "reason", "Deprecated")))
+	// index regex combined with a group inequality
+	assert.Equal(t, 1, col.DeleteMatching(
+		MustNewLabelMatcher(MatchRegexp, "namespace", "tmp-.*"),
+		MustNewLabelMatcher(MatchNotEqual, "condition", "Synced"),
+	))
+	// anchored regex: "tmp" alone matches nothing
+	assert.Equal(t, 0, col.DeleteMatching(MustNewLabelMatcher(MatchRegexp, "namespace", "tmp")))
+
+	expected := `
+# HELP testns_subsys_conditions help text
+# TYPE testns_subsys_conditions gauge
+testns_subsys_conditions{condition="Synced",name="a",namespace="tmp-1",reason="Ok",status="True"} 1
+testns_subsys_conditions{condition="Synced",name="b",namespace="prod",reason="Failed",status="False"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "testns_subsys_conditions"))
+	assert.Equal(t, [][]string{{"prod", "b"}, {"tmp-1", "a"}}, col.Indexes())
+
+	// no matchers remove everything, and the index is pruned
+	assert.Equal(t, 2, col.DeleteMatching())
+	assert.Empty(t, col.indexes)
+	assert.Empty(t, col.indexTrie.root.children)
+}
+
+// Ensure invalid matchers are rejected
+func Test_DynamicGaugeCollector_DeleteMatching_Invalid(t *testing.T) {
+	col := NewGaugeVecSet("testns", "subsys", "conditions", "help text",
+		[]string{"namespace", "name"}, // index
+		[]string{"condition"},         // group
+		"status", "reason",            // extra
+	)
+
+	col.Set(1, []string{"tmp-1", "a"}, []string{"Ready"}, "False", "Deprecated")
+	col.Set(1, []string{"tmp-1", "a"}, []string{"Synced"}, "True", "Ok")
+	col.Set(1, []string{"tmp-2", "b"}, []string{"Ready"}, "True", "Ok")
+	col.Set(1, []string{"prod", "a"}, []string{"Ready"}, "False", "Deprecated")
+	col.Set(1, []string{"prod", "b"}, []string{"Synced"}, "False", "Failed")
+
+	_, err := col.TryDeleteMatching(MustNewLabelMatcher(MatchEqual, "pod", "a"))
+	assert.ErrorIs(t, err, ErrUnknownLabel)
+
+	_, err = NewLabelMatcher(MatchRegexp, "namespace", "tmp-(")
+	assert.Error(t, err)
+	_, err = col.TryDeleteMatching(LabelMatcher{Type: MatchRegexp, Name: "namespace", Value: "tmp-("})
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustNewLabelMatcher(MatchNotRegexp, "namespace", "tmp-(") })
+
+	// matchers built as literals are compiled on use
+	assert.Equal(t, 3, col.DeleteMatching(LabelMatcher{Type: MatchRegexp, Name: "namespace", Value: "tmp-.*"}))
+}
+
+// Ensure the index tree skips indexes whose values don't match without visiting the others' subtrees
+func Test_DynamicGaugeCollector_DeleteMatching_Prunes(t *testing.T) {
+	col := NewGaugeVecSet("testns", "subsys", "conditions", "help text",
+		[]string{"namespace", "name"}, // index
+		[]string{"condition"},         // group
+		"status", "reason",            // extra
+	)
+
+	col.Set(1, []string{"tmp-1", "a"}, []string{"Ready"}, "False", "Deprecated")
+	col.Set(1, []string{"tmp-1", "a"}, []string{"Synced"}, "True", "Ok")
+	col.Set(1, []string{"tmp-2", "b"}, []string{"Ready"}, "True", "Ok")
+	col.Set(1, []string{"prod", "a"}, []string{"Ready"}, "False", "Deprecated")
+	col.Set(1, []string{"prod", "b"}, []string{"Synced"}, "False", "Failed")
+
+	var visited []string
+	keys := col.indexTrie.collectMatching(func(depth int, value string) bool {
+		visited = append(visited, value)
+		return depth != 0 || value == "prod"
+	})
+	assert.Len(t, keys, 2)
+	assert.ElementsMatch(t, []string{"tmp-1", "tmp-2", "prod", "a", "b"}, visited)
+}
+
+// Ensure DeleteWhere removes the series selected by the predicate
+func Test_DynamicGaugeCollector_DeleteWhere(t *testing.T) {
+	col := NewGaugeVecSet("testns", "subsys", "conditions", "help text",
+		[]string{"namespace", "name"}, // index
+		[]string{"condition"},         // group
+		"status", "reason",            // extra
+	)
+
+	col.Set(1, []string{"tmp-1", "a"}, []string{"Ready"}, "False", "Deprecated")
+	col.Set(1, []string{"tmp-1", "a"}, []string{"Synced"}, "True", "Ok")
+	col.Set(1, []string{"tmp-2", "b"}, []string{"Ready"}, "True", "Ok")
+	col.Set(1, []string{"prod", "a"}, []string{"Ready"}, "False", "Deprecated")
+	col.Set(1, []string{"prod", "b"}, []string{"Synced"}, "False", "Failed")
+
+	deleted := col.DeleteWhere(func(indexValues, groupValues, extraValues []string) bool {
+		return indexValues[1] == "a" && extraValues[0] == "False"
+	})
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, 3, testutil.CollectAndCount(col))
+	assert.Equal(t, [][]string{{"prod", "b"}, {"tmp-1", "a"}, {"tmp-2", "b"}}, col.Indexes())
+}
@@ -0,0 +1,109 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+	"time"
+)
+
+// config holds the set-specific settings collected from Options.
+type config struct {
+	errorHandler ErrorHandler
+	enumLabel    string
+	enumStates   []string
+	stateSet     bool
+	ttl          time.Duration
+	now          func() time.Time
+	limits       Limits
+	selfMetrics  bool
+	standalone   bool
+	interning    bool
+	secondary    []string
+}
+
+// Option configures a GaugeVecSet created with NewGaugeVecSetWithOpts or a HierarchicalGaugeSet.
+type Option func(*config)
+
+// WithErrorHandler installs the handler invoked when a non-Try operation fails. See GaugeVecSet.SetErrorHandler.
+func WithErrorHandler(handler ErrorHandler) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = handler
+	}
+}
+
+// WithEnum declares the allowed states of an extra label. See GaugeVecSet.WithEnum.
+func WithEnum(extraLabel string, states ...string) Option {
+	return func(cfg *config) {
+		cfg.enumLabel = extraLabel
+		cfg.enumStates = states
+	}
+}
+
+// WithStateSet exposes the enum declared with WithEnum as an OpenMetrics StateSet. See GaugeVecSet.AsStateSet.
+func WithStateSet() Option {
+	return func(cfg *config) {
+		cfg.stateSet = true
+	}
+}
+
+// WithTTL makes every written series expire once ttl elapsed without another write.
+// Expired series are removed by ExpireStale, which StartJanitor runs periodically.
+func WithTTL(ttl time.Duration) Option {
+	return func(cfg *config) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithClock replaces time.Now as the clock used to stamp writes for TTL expiry. Intended for tests.
+func WithClock(now func() time.Time) Option {
+	return func(cfg *config) {
+		cfg.now = now
+	}
+}
+
+// WithLimits caps the cardinality of the set. See VecSet.SetLimits.
+func WithLimits(limits Limits) Option {
+	return func(cfg *config) {
+		cfg.limits = limits
+	}
+}
+
+// WithSelfMetrics exports the metrics of VecSet.SelfMetrics from the set's own Describe and Collect.
+func WithSelfMetrics() Option {
+	return func(cfg *config) {
+		cfg.selfMetrics = true
+	}
+}
+
+// WithStandaloneStorage makes the set store the series values in its own index instead of a prometheus.GaugeVec,
+// and emit them as const metrics on Collect. This takes less than half the memory per series, at the cost of
+// allocating every exported metric on each scrape. The public semantics of the set are unchanged.
+func WithStandaloneStorage() Option {
+	return func(cfg *config) {
+		cfg.standalone = true
+	}
+}
+
+// WithLabelInterning shares one copy of every distinct label value across the series of the set. The per-series key
+// is not shared, so the saving is small; see VecSet.SetLabelInterning.
+func WithLabelInterning() Option {
+	return func(cfg *config) {
+		cfg.interning = true
+	}
+}
+
+// WithSecondaryIndex maintains a secondary index on each of the given labels for DeleteBy.
+// See VecSet.AddSecondaryIndex.
+func WithSecondaryIndex(labels ...string) Option {
+	return func(cfg *config) {
+		cfg.secondary = append(cfg.secondary, labels...)
+	}
+}
+
+// validateConstLabels panics if a const label has the same name as one of the dynamic labels.
+func validateConstLabels(constLabels map[string]string, allLabels []string) {
+	for _, label := range allLabels {
+		if _, exists := constLabels[label]; exists {
+			panic(fmt.Sprintf("GaugeVecSet: const label %q duplicates a dynamic label", label))
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure const labels from GaugeOpts are attached to every series
+func Test_DynamicGaugeCollector_WithOpts_ConstLabels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{
+			Namespace:   "testns",
+			Subsystem:   "subsys",
+			Name:        "phase",
+			Help:        "help text",
+			ConstLabels: prometheus.Labels{"cluster": "eu1", "shard": "3"},
+		},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithEnum("phase", "Pending", "Running"),
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.SetActiveInGroup(1, []string{"prod"}, []string{"nginx"}, "Running")
+
+	want := `
+# HELP testns_subsys_phase help text
+# TYPE testns_subsys_phase gauge
+testns_subsys_phase{cluster="eu1",namespace="prod",phase="Pending",pod="nginx",shard="3"} 0
+testns_subsys_phase{cluster="eu1",namespace="prod",phase="Running",pod="nginx",shard="3"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_phase"))
+	assert.Equal(t, 2, col.DeleteByIndex("prod"))
+}
+
+func Test_DynamicGaugeCollector_WithOpts_Options(t *testing.T) {
+	var handled []error
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"name"},
+		nil,
+		[]string{"testns_subsys_phase"},
+		WithErrorHandler(func(err error) { handled = append(handled, err) }),
+		WithEnum("testns_subsys_phase", "Pending", "Running"),
+		WithStateSet(),
+	)
+
+	col.Set(2, []string{"a"}, nil, "Pending")
+	col.Set(1, []string{"a"}, nil, "Unknown")
+	require.Len(t, handled, 2)
+	assert.ErrorIs(t, handled[0], ErrStateSetValue)
+	assert.ErrorIs(t, handled[1], ErrUnknownState)
+}
+
+func Test_DynamicGaugeCollector_WithOpts_Panics(t *testing.T) {
+	assert.Panics(t, func() {
+		NewGaugeVecSetWithOpts(
+			prometheus.GaugeOpts{Name: "phase", ConstLabels: prometheus.Labels{"namespace": "x"}},
+			[]string{"namespace"}, nil, nil,
+		)
+	}, "const label duplicates an index label")
+
+	assert.Panics(t, func() {
+		NewGaugeVecSetWithOpts(prometheus.GaugeOpts{Name: "pha-se"}, []string{"namespace"}, nil, nil)
+	}, "invalid metric name")
+
+	assert.Panics(t, func() {
+		NewGaugeVecSetWithOpts(prometheus.GaugeOpts{Name: "phase"}, []string{"namespace"}, nil, nil, WithStateSet())
+	}, "state set without enum")
+}
@@ -0,0 +1,139 @@
+package gauge_vec_set
+
+import (
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Series is a single series below one index: its group and extra label values and its current value.
+type Series struct {
+	GroupValues []string
+	ExtraValues []string
+	Value       float64
+}
+
+// gaugeValue reads the current value of g.
+func gaugeValue(g prometheus.Gauge) float64 {
+	var m dto.Metric
+	_ = g.Write(&m)
+	return m.GetGauge().GetValue()
+}
+
+// readGroupLocked returns the series cached under (indexKey, groupKey) sorted by their extra values.
+// The values are read from the cached child metrics, so reading never re-creates a deleted series.
+// The caller must hold the read lock.
+func (c *GaugeVecSet) readGroupLocked(indexKey, groupKey string) []Series {
+	group := c.indexes[indexKey][groupKey]
+	if len(group) == 0 {
+		return nil
+	}
+
+	series := make([]Series, 0, len(group))
+	for _, s := range group {
+		_, groupValues, extraValues := c.splitValues(slices.Clone(s.values))
+		series = append(series, Series{
+			GroupValues: groupValues,
+			ExtraValues: extraValues,
+			Value:       gaugeValue(s.metric),
+		})
+	}
+	slices.SortFunc(series, func(a, b Series) int {
+		return slices.Compare(a.ExtraValues, b.ExtraValues)
+	})
+	return series
+}
+
+// Get returns the current value of the series identified by (index, group, extra).
+// ok is false if the series is not tracked by the set.
+func (c *GaugeVecSet) Get(indexValues []string, groupValues []string, extraValues ...string) (value float64, ok bool) {
+	value, ok, err := c.TryGet(indexValues, groupValues, extraValues...)
+	if err != nil {
+		c.handleError(err)
+	}
+	return value, ok
+}
+
+// TryGet is like Get but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TryGet(
+	indexValues []string, groupValues []string, extraValues ...string,
+) (value float64, ok bool, err error) {
+	if err := c.validateValues(indexValues, groupValues, extraValues); err != nil {
+		return 0, false, err
+	}
+
+	fullKey := serialize(buildAllValues(indexValues, groupValues, extraValues))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, ok := c.indexes[serialize(indexValues)][serialize(groupValues)][fullKey]
+	if !ok {
+		return 0, false, nil
+	}
+	return gaugeValue(s.metric), true, nil
+}
+
+// GroupValues returns all series tracked under (index, group), sorted by their extra values.
+func (c *GaugeVecSet) GroupValues(indexValues []string, groupValues ...string) []Series {
+	series, err := c.TryGroupValues(indexValues, groupValues...)
+	if err != nil {
+		c.handleError(err)
+	}
+	return series
+}
+
+// TryGroupValues is like GroupValues but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TryGroupValues(indexValues []string, groupValues ...string) ([]Series, error) {
+	if err := c.validateIndexValues(indexValues); err != nil {
+		return nil, err
+	}
+	if err := c.validateGroupValues(groupValues); err != nil {
+		return nil, err
+	}
+
+	indexKey, groupKey := serialize(indexValues), serialize(groupValues)
+
+	// The group's lock keeps transitions from being observed halfway.
+	groupLock := c.groupLocks.stripe(indexKey, groupKey)
+	groupLock.RLock()
+	defer groupLock.RUnlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.readGroupLocked(indexKey, groupKey), nil
+}
+
+// ActiveInGroup returns the extra values and value of the single non-zero series in (index, group), as maintained
+// by SetActiveInGroup and SetGroup. ok is false if the group has no non-zero series, or more than one.
+func (c *GaugeVecSet) ActiveInGroup(
+	indexValues []string, groupValues ...string,
+) (extraValues []string, value float64, ok bool) {
+	extraValues, value, ok, err := c.TryActiveInGroup(indexValues, groupValues...)
+	if err != nil {
+		c.handleError(err)
+	}
+	return extraValues, value, ok
+}
+
+// TryActiveInGroup is like ActiveInGroup but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TryActiveInGroup(
+	indexValues []string, groupValues ...string,
+) (extraValues []string, value float64, ok bool, err error) {
+	series, err := c.TryGroupValues(indexValues, groupValues...)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	for _, s := range series {
+		if s.Value == 0 {
+			continue
+		}
+		if ok {
+			return nil, 0, false, nil
+		}
+		extraValues, value, ok = s.ExtraValues, s.Value, true
+	}
+	return extraValues, value, ok, nil
+}
@@ -0,0 +1,70 @@
+package gauge_vec_set
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure the current values can be read back without scraping
+func Test_DynamicGaugeCollector_ReadAPI(t *testing.T) {
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"conditions_read",
+		"help text",
+		[]string{"controller", "name"}, // index
+		[]string{"condition"},          // group
+		"status", "reason",             // extra
+	)
+
+	idx := []string{"ctrl", "obj"}
+
+	_, ok := col.Get(idx, []string{"Ready"}, "True", "")
+	assert.False(t, ok)
+	_, _, ok = col.ActiveInGroup(idx, "Ready")
+	assert.False(t, ok)
+	assert.Empty(t, col.GroupValues(idx, "Ready"))
+
+	col.SetActiveInGroup(1, idx, []string{"Ready"}, "True", "")
+	col.SetActiveInGroup(2, idx, []string{"Ready"}, "False", "bad_secret")
+
+	value, ok := col.Get(idx, []string{"Ready"}, "True", "")
+	assert.True(t, ok)
+	assert.Equal(t, float64(0), value)
+
+	value, ok = col.Get(idx, []string{"Ready"}, "False", "bad_secret")
+	assert.True(t, ok)
+	assert.Equal(t, float64(2), value)
+
+	extra, value, ok := col.ActiveInGroup(idx, "Ready")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"False", "bad_secret"}, extra)
+	assert.Equal(t, float64(2), value)
+
+	assert.Equal(t, []Series{
+		{GroupValues: []string{"Ready"}, ExtraValues: []string{"False", "bad_secret"}, Value: 2},
+		{GroupValues: []string{"Ready"}, ExtraValues: []string{"True", ""}, Value: 0},
+	}, col.GroupValues(idx, "Ready"))
+
+	// Two non-zero variants are ambiguous
+	col.Set(1, idx, []string{"Ready"}, "Unknown", "")
+	_, _, ok = col.ActiveInGroup(idx, "Ready")
+	assert.False(t, ok)
+
+	// Reading must not re-create deleted series
+	assert.Equal(t, 3, col.DeleteByIndex(idx...))
+	_, ok = col.Get(idx, []string{"Ready"}, "True", "")
+	assert.False(t, ok)
+	assert.Empty(t, col.GroupValues(idx, "Ready"))
+	assert.Equal(t, 0, testutil.CollectAndCount(col))
+
+	_, _, err := col.TryGet(idx, nil, "True", "")
+	assert.ErrorIs(t, err, ErrGroupArity)
+	_, err = col.TryGroupValues([]string{"ctrl"}, "Ready")
+	assert.ErrorIs(t, err, ErrIndexArity)
+	_, _, _, err = col.TryActiveInGroup(idx)
+	require.ErrorIs(t, err, ErrGroupArity)
+}
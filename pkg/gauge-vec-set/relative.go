@@ -0,0 +1,156 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// update applies op to the series identified by allValues and caches it under (indexKey, groupKey).
+func (c *GaugeVecSet) update(indexKey, groupKey string, allValues []string, op func(prometheus.Gauge)) error {
+	ref, err := c.admit(indexKey, groupKey, allValues)
+	if err != nil {
+		return err
+	}
+	g := c.child(ref)
+	op(g)
+	c.cacheWithKeys(ref, g)
+	return nil
+}
+
+// tryUpdate validates the label values and applies op to the identified series.
+// If exclusive is true, all other series in the same (index, group) are deleted first, under the group's lock
+// so that Collect observes the group either before or after the transition.
+func (c *GaugeVecSet) tryUpdate(
+	exclusive bool, indexValues, groupValues, extraValues []string, op func(prometheus.Gauge),
+) error {
+	if err := c.validateValues(indexValues, groupValues, extraValues); err != nil {
+		return err
+	}
+	if c.enum != nil && c.enum.stateSet {
+		return fmt.Errorf("%w: relative updates are not supported", ErrStateSetValue)
+	}
+
+	indexKey := serialize(indexValues)
+	groupKey := serialize(groupValues)
+	allValues := buildAllValues(indexValues, groupValues, extraValues)
+	if !exclusive || len(c.groupLabels) == 0 {
+		return c.update(indexKey, groupKey, allValues, op)
+	}
+
+	groupLock := c.groupLocks.stripe(indexKey, groupKey)
+	groupLock.Lock()
+	defer groupLock.Unlock()
+
+	c.mu.Lock()
+	fullKey := serialize(allValues)
+	var siblings [][]string
+	for key, s := range c.indexes[indexKey][groupKey] {
+		if key != fullKey {
+			c.uncacheLocked(indexKey, groupKey, key)
+			siblings = append(siblings, s.values)
+		}
+	}
+	ref, err := c.admitLocked(indexKey, groupKey, allValues)
+	c.mu.Unlock()
+
+	c.deleteSeries(siblings)
+	if err != nil {
+		return err
+	}
+	g := c.child(ref)
+	op(g)
+	c.cacheWithKeys(ref, g)
+	return nil
+}
+
+// Add adds value (which may be negative) to the series identified by (index, group, extra).
+// Like Set, this does not modify sibling series.
+func (c *GaugeVecSet) Add(value float64, indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TryAdd(value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TryAdd is like Add but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TryAdd(value float64, indexValues []string, groupValues []string, extraValues ...string) error {
+	return c.tryUpdate(false, indexValues, groupValues, extraValues, func(g prometheus.Gauge) { g.Add(value) })
+}
+
+// Sub subtracts value from the series identified by (index, group, extra).
+func (c *GaugeVecSet) Sub(value float64, indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TrySub(value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySub is like Sub but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TrySub(value float64, indexValues []string, groupValues []string, extraValues ...string) error {
+	return c.tryUpdate(false, indexValues, groupValues, extraValues, func(g prometheus.Gauge) { g.Sub(value) })
+}
+
+// Inc increments the series identified by (index, group, extra) by 1.
+func (c *GaugeVecSet) Inc(indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TryInc(indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TryInc is like Inc but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TryInc(indexValues []string, groupValues []string, extraValues ...string) error {
+	return c.tryUpdate(false, indexValues, groupValues, extraValues, prometheus.Gauge.Inc)
+}
+
+// Dec decrements the series identified by (index, group, extra) by 1.
+func (c *GaugeVecSet) Dec(indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TryDec(indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TryDec is like Dec but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TryDec(indexValues []string, groupValues []string, extraValues ...string) error {
+	return c.tryUpdate(false, indexValues, groupValues, extraValues, prometheus.Gauge.Dec)
+}
+
+// AddGroup deletes all other series for (index, group) and then adds value to the given one.
+// Unlike SetGroup, the value of the target series is kept if it already exists.
+func (c *GaugeVecSet) AddGroup(value float64, indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TryAddGroup(value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TryAddGroup is like AddGroup but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TryAddGroup(
+	value float64, indexValues []string, groupValues []string, extraValues ...string,
+) error {
+	return c.tryUpdate(true, indexValues, groupValues, extraValues, func(g prometheus.Gauge) { g.Add(value) })
+}
+
+// SetToCurrentTime sets the series identified by (index, group, extra) to the current Unix time in seconds.
+func (c *GaugeVecSet) SetToCurrentTime(indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TrySetToCurrentTime(indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySetToCurrentTime is like SetToCurrentTime but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TrySetToCurrentTime(indexValues []string, groupValues []string, extraValues ...string) error {
+	return c.tryUpdate(false, indexValues, groupValues, extraValues, prometheus.Gauge.SetToCurrentTime)
+}
+
+// SetToCurrentTimeGroup deletes all other series for (index, group) and then sets the given one to the
+// current Unix time in seconds. Useful for "last transition time" style metrics.
+func (c *GaugeVecSet) SetToCurrentTimeGroup(indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TrySetToCurrentTimeGroup(indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySetToCurrentTimeGroup is like SetToCurrentTimeGroup but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TrySetToCurrentTimeGroup(
+	indexValues []string, groupValues []string, extraValues ...string,
+) error {
+	return c.tryUpdate(true, indexValues, groupValues, extraValues, prometheus.Gauge.SetToCurrentTime)
+}
@@ -0,0 +1,88 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure relative operations accumulate and remain deletable by index and group
+func Test_DynamicGaugeCollector_RelativeOperations(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"inflight",
+		"help text",
+		[]string{"namespace"}, // index
+		[]string{"queue"},     // group
+		"priority",            // extra
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Inc([]string{"ns1"}, []string{"q1"}, "high")
+	col.Inc([]string{"ns1"}, []string{"q1"}, "high")
+	col.Add(3, []string{"ns1"}, []string{"q1"}, "low")
+	col.Sub(1, []string{"ns1"}, []string{"q1"}, "low")
+	col.Dec([]string{"ns1"}, []string{"q2"}, "low")
+	col.Add(5, []string{"ns2"}, []string{"q1"}, "low")
+
+	want := `
+# HELP testns_subsys_inflight help text
+# TYPE testns_subsys_inflight gauge
+testns_subsys_inflight{namespace="ns1",priority="high",queue="q1"} 2
+testns_subsys_inflight{namespace="ns1",priority="low",queue="q1"} 2
+testns_subsys_inflight{namespace="ns1",priority="low",queue="q2"} -1
+testns_subsys_inflight{namespace="ns2",priority="low",queue="q1"} 5
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_inflight"))
+
+	// The exclusive variant drops siblings but keeps accumulating on the target
+	col.AddGroup(1, []string{"ns1"}, []string{"q1"}, "low")
+
+	want = `
+# HELP testns_subsys_inflight help text
+# TYPE testns_subsys_inflight gauge
+testns_subsys_inflight{namespace="ns1",priority="low",queue="q1"} 3
+testns_subsys_inflight{namespace="ns1",priority="low",queue="q2"} -1
+testns_subsys_inflight{namespace="ns2",priority="low",queue="q1"} 5
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_inflight"))
+
+	assert.Equal(t, 1, col.DeleteByGroup([]string{"ns1"}, "q1"))
+	assert.Equal(t, 1, col.DeleteByIndex("ns1"))
+	assert.Equal(t, 1, col.DeleteByIndex("ns2"))
+
+	assert.ErrorIs(t, col.TryInc([]string{"ns1"}, nil, "high"), ErrGroupArity)
+	assert.ErrorIs(t, col.TryAdd(1, []string{"ns1"}, []string{"q1"}), ErrExtraArity)
+	assert.Empty(t, col.indexes)
+}
+
+func Test_DynamicGaugeCollector_SetToCurrentTime(t *testing.T) {
+	col := NewGaugeVecSet(
+		"testns",
+		"subsys",
+		"last_transition",
+		"help text",
+		[]string{"name"},      // index
+		[]string{"condition"}, // group
+		"status",              // extra
+	)
+
+	before := float64(time.Now().Unix())
+	col.SetToCurrentTime([]string{"obj"}, []string{"Ready"}, "True")
+	col.SetToCurrentTimeGroup([]string{"obj"}, []string{"Ready"}, "False")
+	after := float64(time.Now().Unix() + 1)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(col))
+	value := testutil.ToFloat64(col.metric.WithLabelValues("obj", "Ready", "False"))
+	assert.GreaterOrEqual(t, value, before)
+	assert.LessOrEqual(t, value, after)
+	assert.Equal(t, 1, col.DeleteByGroup([]string{"obj"}, "Ready"))
+}
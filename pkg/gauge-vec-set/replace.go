@@ -0,0 +1,146 @@
+package gauge_vec_set
+
+// ReplaceIndex makes desired the complete set of series under indexValues: series of the index that are not
+// desired are deleted, and every desired series is set to its value. Returns the number of series added, updated
+// (set to a different value) and deleted; series folded into the overflow series under OverflowFold are not counted
+// as added.
+//
+// The replacement is atomic with respect to Collect, so a scrape observes the index either before or after it.
+// Desired series are written as given: unlike SetActiveInGroup, no enum states are materialized. If several
+// desired series share their group and extra values, the last one wins.
+//
+// Example:
+//
+//	col.ReplaceIndex([]string{"prod", "nginx"}, []Series{
+//		{GroupValues: []string{"Ready"}, ExtraValues: []string{"True"}, Value: 1},
+//		{GroupValues: []string{"Synced"}, ExtraValues: []string{"False"}, Value: 1},
+//	})
+func (c *GaugeVecSet) ReplaceIndex(indexValues []string, desired []Series) (added, updated, deleted int) {
+	added, updated, deleted, err := c.TryReplaceIndex(indexValues, desired)
+	if err != nil {
+		c.handleError(err)
+	}
+	return added, updated, deleted
+}
+
+// TryReplaceIndex is like ReplaceIndex but returns an error instead of invoking the error handler.
+//
+// Every desired series is validated before the index is changed, so an arity or enum error leaves it untouched.
+// Desired series rejected by the limits are skipped, and the first rejection is returned once the others are
+// written.
+func (c *GaugeVecSet) TryReplaceIndex(
+	indexValues []string, desired []Series,
+) (added, updated, deleted int, err error) {
+	if err := c.validateIndexValues(indexValues); err != nil {
+		return 0, 0, 0, err
+	}
+	return c.replaceIndex(indexValues, serialize(indexValues), desired)
+}
+
+// replacement is a desired series of ReplaceIndex.
+type replacement struct {
+	ref   seriesRef
+	value float64
+	// created is set if the series is not in the index yet.
+	created bool
+}
+
+// replaceIndex implements TryReplaceIndex for validated index values.
+func (c *GaugeVecSet) replaceIndex(
+	indexValues []string, indexKey string, desired []Series,
+) (added, updated, deleted int, err error) {
+	replacements := make([]replacement, 0, len(desired))
+	positions := make(map[string]int, len(desired))
+	for _, s := range desired {
+		if err := c.validateGroupValues(s.GroupValues); err != nil {
+			return 0, 0, 0, err
+		}
+		if err := c.validateExtraValues(s.ExtraValues); err != nil {
+			return 0, 0, 0, err
+		}
+		if err := c.validateStateValue(s.Value); err != nil {
+			return 0, 0, 0, err
+		}
+
+		allValues := buildAllValues(indexValues, s.GroupValues, s.ExtraValues)
+		ref := seriesRef{
+			indexKey:  indexKey,
+			groupKey:  serialize(s.GroupValues),
+			fullKey:   serialize(allValues),
+			allValues: allValues,
+		}
+		if i, ok := positions[ref.fullKey]; ok {
+			replacements[i].value = s.Value
+			continue
+		}
+		positions[ref.fullKey] = len(replacements)
+		replacements = append(replacements, replacement{ref: ref, value: s.Value})
+	}
+
+	// Holding the locks of the cached and desired groups excludes their exclusive writers and Collect.
+	desiredStripes := make([]int, 0, len(replacements))
+	for _, r := range replacements {
+		desiredStripes = append(desiredStripes, stripeOf(indexKey, r.ref.groupKey))
+	}
+	stripes := c.lockBelow(indexValues, desiredStripes...)
+	defer c.groupLocks.unlockStripes(stripes)
+
+	c.mu.Lock()
+	var stale [][]string
+	for groupKey, group := range c.indexes[indexKey] {
+		for fullKey := range group {
+			if _, ok := positions[fullKey]; !ok {
+				s, _ := c.uncacheLocked(indexKey, groupKey, fullKey)
+				stale = append(stale, s.values)
+			}
+		}
+	}
+	for i := range replacements {
+		r := &replacements[i]
+		s, ok := c.indexes[indexKey][r.ref.groupKey][r.ref.fullKey]
+		switch {
+		case !ok:
+			r.created = true
+		case gaugeValue(s.metric) != r.value:
+			updated++
+		}
+	}
+	c.mu.Unlock()
+
+	// No locks but the groups' are held during Prometheus calls.
+	deleted = c.deleteSeries(stale)
+
+	// New series are admitted one at a time, so each counts against the limits before the next is checked.
+	for _, r := range replacements {
+		ref := r.ref
+		if r.created {
+			var admitErr error
+			if ref, admitErr = c.admit(ref.indexKey, ref.groupKey, ref.allValues); admitErr != nil {
+				if err == nil {
+					err = admitErr
+				}
+				continue
+			}
+			if !ref.folded {
+				added++
+			}
+		}
+		g := c.child(ref)
+		g.Set(r.value)
+		c.cacheWithKeys(ref, g)
+	}
+	return added, updated, deleted, err
+}
+
+// Replace is GaugeVecSet.ReplaceIndex for the bound index.
+func (h *IndexHandle) Replace(desired []Series) (added, updated, deleted int) {
+	if h.err != nil {
+		h.set.handleError(h.err)
+		return 0, 0, 0
+	}
+	added, updated, deleted, err := h.set.replaceIndex(h.indexValues, h.indexKey, desired)
+	if err != nil {
+		h.set.handleError(err)
+	}
+	return added, updated, deleted
+}
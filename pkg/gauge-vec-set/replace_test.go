@@ -0,0 +1,166 @@
+package gauge_vec_set
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure ReplaceIndex converges the index onto the desired series and reports the diff
+func Test_DynamicGaugeCollector_ReplaceIndex(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewGaugeVecSet("testns", "subsys", "conditions", "help text",
+		[]string{"namespace", "name"}, []string{"condition"}, "status")
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, []string{"prod", "nginx"}, []string{"Ready"}, "False")
+	col.Set(1, []string{"prod", "nginx"}, []string{"Synced"}, "True")
+	col.Set(1, []string{"prod", "other"}, []string{"Ready"}, "True")
+
+	added, updated, deleted := col.ReplaceIndex([]string{"prod", "nginx"}, []Series{
+		{GroupValues: []string{"Ready"}, ExtraValues: []string{"True"}, Value: 1},
+		{GroupValues: []string{"Synced"}, ExtraValues: []string{"True"}, Value: 2},
+		{GroupValues: []string{"Degraded"}, ExtraValues: []string{"False"}, Value: 0},
+		{GroupValues: []string{"Degraded"}, ExtraValues: []string{"False"}, Value: 1}, // last one wins
+	})
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, 1, deleted)
+
+	expected := `
+# HELP testns_subsys_conditions help text
+# TYPE testns_subsys_conditions gauge
+testns_subsys_conditions{condition="Degraded",name="nginx",namespace="prod",status="False"} 1
+testns_subsys_conditions{condition="Ready",name="nginx",namespace="prod",status="True"} 1
+testns_subsys_conditions{condition="Ready",name="other",namespace="prod",status="True"} 1
+testns_subsys_conditions{condition="Synced",name="nginx",namespace="prod",status="True"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "testns_subsys_conditions"))
+
+	// Replacing with the same series is a no-op.
+	added, updated, deleted = col.BindIndex("prod", "nginx").Replace([]Series{
+		{GroupValues: []string{"Ready"}, ExtraValues: []string{"True"}, Value: 1},
+		{GroupValues: []string{"Synced"}, ExtraValues: []string{"True"}, Value: 2},
+		{GroupValues: []string{"Degraded"}, ExtraValues: []string{"False"}, Value: 1},
+	})
+	assert.Equal(t, [3]int{0, 0, 0}, [3]int{added, updated, deleted})
+
+	// Replacing with nothing deletes the index.
+	added, updated, deleted = col.ReplaceIndex([]string{"prod", "nginx"}, nil)
+	assert.Equal(t, [3]int{0, 0, 3}, [3]int{added, updated, deleted})
+	assert.Equal(t, [][]string{{"prod", "other"}}, col.Indexes())
+}
+
+// Ensure an invalid desired series leaves the index untouched
+func Test_DynamicGaugeCollector_ReplaceIndex_Invalid(t *testing.T) {
+	col := NewGaugeVecSet("testns", "subsys", "conditions", "help text",
+		[]string{"namespace", "name"}, []string{"condition"}, "status")
+	col.Set(1, []string{"prod", "nginx"}, []string{"Ready"}, "False")
+
+	_, _, _, err := col.TryReplaceIndex([]string{"prod"}, nil)
+	assert.ErrorIs(t, err, ErrIndexArity)
+
+	_, _, _, err = col.TryReplaceIndex([]string{"prod", "nginx"}, []Series{
+		{GroupValues: []string{"Ready"}, ExtraValues: []string{"True"}, Value: 1},
+		{GroupValues: []string{"Synced"}, Value: 1},
+	})
+	assert.ErrorIs(t, err, ErrExtraArity)
+
+	value, ok := col.Get([]string{"prod", "nginx"}, []string{"Ready"}, "False")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, value)
+	assert.Equal(t, 1, testutil.CollectAndCount(col))
+}
+
+// Ensure series rejected by the limits are skipped while the others are written
+func Test_DynamicGaugeCollector_ReplaceIndex_Limits(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "conditions", Help: "help text"},
+		[]string{"namespace", "name"}, []string{"condition"}, []string{"status"},
+		WithLimits(Limits{MaxSeriesPerIndex: 2}),
+	)
+	col.Set(1, []string{"prod", "nginx"}, []string{"Ready"}, "False")
+	col.Set(1, []string{"prod", "nginx"}, []string{"Synced"}, "False")
+
+	added, updated, deleted, err := col.TryReplaceIndex([]string{"prod", "nginx"}, []Series{
+		{GroupValues: []string{"Ready"}, ExtraValues: []string{"True"}, Value: 1},
+		{GroupValues: []string{"Synced"}, ExtraValues: []string{"True"}, Value: 1},
+		{GroupValues: []string{"Degraded"}, ExtraValues: []string{"True"}, Value: 1},
+	})
+	assert.ErrorIs(t, err, ErrCardinalityLimit)
+	assert.Equal(t, [3]int{2, 0, 2}, [3]int{added, updated, deleted})
+	assert.Equal(t, [][]string{{"Ready"}, {"Synced"}}, col.Groups("prod", "nginx"))
+}
+
+// Ensure series folded into the overflow series are not counted as added
+func Test_DynamicGaugeCollector_ReplaceIndex_LimitsFold(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "conditions", Help: "help text"},
+		[]string{"namespace", "name"}, []string{"condition"}, []string{"status"},
+		WithLimits(Limits{MaxSeriesPerIndex: 2, Policy: OverflowFold}),
+	)
+
+	added, updated, deleted, err := col.TryReplaceIndex([]string{"prod", "nginx"}, []Series{
+		{GroupValues: []string{"Ready"}, ExtraValues: []string{"True"}, Value: 1},
+		{GroupValues: []string{"Synced"}, ExtraValues: []string{"True"}, Value: 1},
+		{GroupValues: []string{"Degraded"}, ExtraValues: []string{"True"}, Value: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [3]int{2, 0, 0}, [3]int{added, updated, deleted})
+	assert.Equal(t, 3, testutil.CollectAndCount(col))
+}
+
+// Ensure scrapes observe an index either before or after a replacement, never a mix of both
+func Test_DynamicGaugeCollector_ReplaceIndex_ScrapeConsistent(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewGaugeVecSet("testns", "subsys", "conditions", "help text",
+		[]string{"namespace"}, []string{"condition"}, "status")
+	require.NoError(t, reg.Register(col))
+
+	// Both states have the same number of series, but share none of them.
+	states := [][]Series{make([]Series, 8), make([]Series, 8)}
+	for i := range 8 {
+		condition := string(rune('a' + i))
+		states[0][i] = Series{GroupValues: []string{condition}, ExtraValues: []string{"True"}, Value: 1}
+		states[1][i] = Series{GroupValues: []string{condition}, ExtraValues: []string{"False"}, Value: 1}
+	}
+	col.ReplaceIndex([]string{"ns1"}, states[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; ctx.Err() == nil; i++ {
+			col.ReplaceIndex([]string{"ns1"}, states[i%2])
+		}
+	}()
+
+	for ctx.Err() == nil {
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		require.Len(t, families, 1)
+		statuses := make(map[string]int)
+		for _, m := range families[0].GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "status" {
+					statuses[label.GetValue()]++
+				}
+			}
+		}
+		require.Len(t, statuses, 1, "scrape mixed two states: %v", statuses)
+		for _, n := range statuses {
+			require.Equal(t, 8, n)
+		}
+	}
+	wg.Wait()
+}
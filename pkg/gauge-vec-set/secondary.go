@@ -0,0 +1,116 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+	"slices"
+)
+
+// secondaryIndex maps the values of one label to the series carrying them, so deleting by that label only visits
+// the matching series.
+type secondaryIndex struct {
+	// position of the label within index + group + extra labels.
+	position int
+	// series by label value: value -> fullKey -> keys of the series in the nested index.
+	series map[string]map[string]seriesKeys
+}
+
+// seriesKeys locates a series in the nested index. The keys are substrings of its fullKey, so they share memory.
+type seriesKeys struct {
+	indexKey string
+	groupKey string
+}
+
+// insert records the series ref.
+func (s *secondaryIndex) insert(ref seriesRef) {
+	value := ref.allValues[s.position]
+	bucket, ok := s.series[value]
+	if !ok {
+		bucket = make(map[string]seriesKeys)
+		s.series[value] = bucket
+	}
+	groupEnd := len(ref.indexKey) + len(ref.groupKey)
+	bucket[ref.fullKey] = seriesKeys{
+		indexKey: ref.fullKey[:len(ref.indexKey)],
+		groupKey: ref.fullKey[len(ref.indexKey):groupEnd],
+	}
+}
+
+// remove forgets the series fullKey, whose label values are values.
+func (s *secondaryIndex) remove(values []string, fullKey string) {
+	value := values[s.position]
+	bucket := s.series[value]
+	delete(bucket, fullKey)
+	if len(bucket) == 0 {
+		delete(s.series, value)
+	}
+}
+
+// AddSecondaryIndex maintains an index of the series by the value of label, which may be any index, group or extra
+// label, so that DeleteBy(label, value) only visits the matching series instead of scanning the whole set.
+// Series already in the set are indexed right away.
+//
+// Every secondary index costs one map entry per series and slows down the creation and deletion of series.
+// AddSecondaryIndex panics if label is not a label of the set or already has a secondary index. Call it right after
+// construction, before the set is used concurrently.
+func (c *VecSet[T]) AddSecondaryIndex(label string) {
+	position := slices.Index(buildAllValues(c.indexLabels, c.groupLabels, c.extraLabels), label)
+	if position < 0 {
+		panic(fmt.Sprintf("GaugeVecSet: secondary index label %q is not a label of the set", label))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.secondary[label]; exists {
+		panic(fmt.Sprintf("GaugeVecSet: duplicate secondary index on label %q", label))
+	}
+	secondary := &secondaryIndex{position: position, series: make(map[string]map[string]seriesKeys)}
+	for indexKey, groupMap := range c.indexes {
+		for groupKey, group := range groupMap {
+			for fullKey, s := range group {
+				secondary.insert(seriesRef{indexKey: indexKey, groupKey: groupKey, fullKey: fullKey, allValues: s.values})
+			}
+		}
+	}
+	if c.secondary == nil {
+		c.secondary = make(map[string]*secondaryIndex)
+	}
+	c.secondary[label] = secondary
+}
+
+// DeleteBy removes all series whose label has the given value, using the secondary index declared for label
+// (see AddSecondaryIndex and WithSecondaryIndex), and prunes groups and indexes left empty.
+// Returns the number of deleted series.
+//
+// Example:
+//
+//	col := NewGaugeVecSetWithOpts(opts, []string{"namespace"}, []string{"pod"}, []string{"node"},
+//		WithSecondaryIndex("node"))
+//	col.DeleteBy("node", "n1")
+func (c *VecSet[T]) DeleteBy(label, value string) (deleted int) {
+	deleted, err := c.TryDeleteBy(label, value)
+	if err != nil {
+		c.handleError(err)
+	}
+	return deleted
+}
+
+// TryDeleteBy is like DeleteBy but returns an error instead of invoking the error handler.
+// Labels without a secondary index are rejected with ErrUnknownLabel.
+func (c *VecSet[T]) TryDeleteBy(label, value string) (deleted int, err error) {
+	c.mu.Lock()
+	secondary, ok := c.secondary[label]
+	if !ok {
+		c.mu.Unlock()
+		return 0, fmt.Errorf("%w: no secondary index on %q", ErrUnknownLabel, label)
+	}
+	var detached [][]string
+	for fullKey, keys := range secondary.series[value] {
+		if s, ok := c.uncacheLocked(keys.indexKey, keys.groupKey, fullKey); ok {
+			detached = append(detached, s.values)
+		}
+	}
+	c.mu.Unlock()
+
+	return c.deleteSeries(detached), nil
+}
@@ -0,0 +1,111 @@
+package gauge_vec_set
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure DeleteBy removes the series with the given value of a secondary index label across all indexes
+func Test_DynamicGaugeCollector_SecondaryIndex(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"namespace"},     // index
+		[]string{"pod"},           // group
+		[]string{"node", "phase"}, // extra
+		WithSecondaryIndex("node", "pod"),
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, []string{"ns1"}, []string{"a"}, "n1", "Running")
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"b"}, "n1", "Running")
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"b"}, "n2", "Pending")
+	col.Set(1, []string{"ns2"}, []string{"a"}, "n1", "Running")
+	col.Set(1, []string{"ns2"}, []string{"c"}, "n2", "Running")
+
+	assert.Equal(t, 3, col.DeleteBy("node", "n1"))
+	assert.Equal(t, 0, col.DeleteBy("node", "n1"))
+	assert.Equal(t, 0, col.DeleteBy("node", "n3"))
+
+	expected := `
+# HELP testns_subsys_phase help text
+# TYPE testns_subsys_phase gauge
+testns_subsys_phase{namespace="ns1",node="n2",phase="Pending",pod="b"} 1
+testns_subsys_phase{namespace="ns2",node="n2",phase="Running",pod="c"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "testns_subsys_phase"))
+	assert.Equal(t, [][]string{{"ns1"}, {"ns2"}}, col.Indexes())
+
+	// Deletions by other means keep the secondary indexes in sync.
+	col.DeleteByIndex("ns2")
+	assert.NotContains(t, col.secondary["pod"].series, "c")
+	assert.Equal(t, 1, col.DeleteBy("pod", "b"))
+	assert.Empty(t, col.indexes)
+	assert.Empty(t, col.secondary["node"].series)
+	assert.Empty(t, col.secondary["pod"].series)
+}
+
+// Ensure a secondary index added to a populated set covers the existing series
+func Test_DynamicGaugeCollector_SecondaryIndex_Populated(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"namespace"},     // index
+		[]string{"pod"},           // group
+		[]string{"node", "phase"}, // extra
+	)
+	col.Set(1, []string{"ns1"}, []string{"a"}, "n1", "Running")
+	col.Set(1, []string{"ns2"}, []string{"a"}, "n1", "Running")
+
+	_, err := col.TryDeleteBy("node", "n1")
+	assert.ErrorIs(t, err, ErrUnknownLabel)
+
+	col.AddSecondaryIndex("namespace")
+	col.AddSecondaryIndex("node")
+	assert.Equal(t, 1, col.DeleteBy("namespace", "ns2"))
+	assert.Equal(t, 1, col.DeleteBy("node", "n1"))
+	assert.Empty(t, col.indexes)
+}
+
+// Ensure series removed by eviction or expiry leave the secondary index
+func Test_DynamicGaugeCollector_SecondaryIndex_EvictionAndExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"namespace"},     // index
+		[]string{"pod"},           // group
+		[]string{"node", "phase"}, // extra
+		WithSecondaryIndex("node"),
+		WithLimits(Limits{MaxSeries: 1, Policy: OverflowEvict}),
+		WithTTL(time.Minute),
+		WithClock(func() time.Time { return now }),
+	)
+
+	col.Set(1, []string{"ns1"}, []string{"a"}, "n1", "Running")
+	col.Set(1, []string{"ns2"}, []string{"a"}, "n2", "Running") // evicts ns1
+	assert.Equal(t, []string{"n2"}, slices.Collect(maps.Keys(col.secondary["node"].series)))
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, 1, col.ExpireStale())
+	assert.Empty(t, col.secondary["node"].series)
+}
+
+// Ensure secondary indexes on unknown or duplicate labels are rejected
+func Test_DynamicGaugeCollector_SecondaryIndex_Panics(t *testing.T) {
+	opts := prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"}
+	index, group, extra := []string{"namespace"}, []string{"pod"}, []string{"node", "phase"}
+
+	assert.Panics(t, func() {
+		NewGaugeVecSetWithOpts(opts, index, group, extra, WithSecondaryIndex("zone"))
+	}, "unknown label")
+	assert.Panics(t, func() {
+		NewGaugeVecSetWithOpts(opts, index, group, extra, WithSecondaryIndex("node", "node"))
+	}, "duplicate")
+}
@@ -0,0 +1,80 @@
+package gauge_vec_set
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// selfMetricsLabel is the label carrying the fully-qualified metric name of the set a self metric describes.
+const selfMetricsLabel = "set"
+
+// selfCollector exports the bookkeeping of a VecSet as metrics, labelled with the set's fully-qualified name.
+type selfCollector[T any] struct {
+	set *VecSet[T]
+
+	series        *prometheus.Desc
+	indexes       *prometheus.Desc
+	groups        *prometheus.Desc
+	deletes       *prometheus.Desc
+	evictions     *prometheus.Desc
+	panicsAvoided *prometheus.Desc
+}
+
+func newSelfCollector[T any](set *VecSet[T]) *selfCollector[T] {
+	labels := prometheus.Labels{selfMetricsLabel: set.fqName}
+	return &selfCollector[T]{
+		set: set,
+		series: prometheus.NewDesc("gaugevecset_series",
+			"Number of series tracked by the set.", nil, labels),
+		indexes: prometheus.NewDesc("gaugevecset_indexes",
+			"Number of index label-values tuples tracked by the set.", nil, labels),
+		groups: prometheus.NewDesc("gaugevecset_groups",
+			"Number of (index, group) label-values tuples tracked by the set.", nil, labels),
+		deletes: prometheus.NewDesc("gaugevecset_deletes_total",
+			"Number of series deleted from the set, including expired and evicted series.", nil, labels),
+		evictions: prometheus.NewDesc("gaugevecset_evictions_total",
+			"Number of series evicted by the set's cardinality limits.", nil, labels),
+		panicsAvoided: prometheus.NewDesc("gaugevecset_panics_avoided_total",
+			"Number of errors passed to a non-panicking error handler.", nil, labels),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (s *selfCollector[T]) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.series
+	ch <- s.indexes
+	ch <- s.groups
+	ch <- s.deletes
+	ch <- s.evictions
+	ch <- s.panicsAvoided
+}
+
+// Collect implements prometheus.Collector.
+func (s *selfCollector[T]) Collect(ch chan<- prometheus.Metric) {
+	s.set.mu.RLock()
+	series := s.set.series
+	indexes := len(s.set.indexes)
+	var groups int
+	for _, groupMap := range s.set.indexes {
+		groups += len(groupMap)
+	}
+	s.set.mu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(s.series, prometheus.GaugeValue, float64(series))
+	ch <- prometheus.MustNewConstMetric(s.indexes, prometheus.GaugeValue, float64(indexes))
+	ch <- prometheus.MustNewConstMetric(s.groups, prometheus.GaugeValue, float64(groups))
+	ch <- prometheus.MustNewConstMetric(s.deletes, prometheus.CounterValue, float64(s.set.deletes.Load()))
+	ch <- prometheus.MustNewConstMetric(s.evictions, prometheus.CounterValue, float64(s.set.evicted.Load()))
+	ch <- prometheus.MustNewConstMetric(s.panicsAvoided, prometheus.CounterValue, float64(s.set.handled.Load()))
+}
+
+// SelfMetrics returns a collector exporting metrics about the set itself, labelled with set="<fqName>":
+//   - gaugevecset_series, gaugevecset_indexes and gaugevecset_groups: the number of tracked series, indexes and
+//     groups.
+//   - gaugevecset_deletes_total: the number of deleted series, including expired and evicted ones.
+//   - gaugevecset_evictions_total: the number of series evicted by the cardinality limits.
+//   - gaugevecset_panics_avoided_total: the number of errors passed to an error handler other than the default.
+//
+// Register it next to the set, or create the set with WithSelfMetrics to export them from the set's own Collect.
+func (c *VecSet[T]) SelfMetrics() prometheus.Collector {
+	return newSelfCollector(c)
+}
@@ -0,0 +1,85 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure WithSelfMetrics exports the set's bookkeeping next to its own series
+func Test_DynamicGaugeCollector_SelfMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "self", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithSelfMetrics(),
+		WithLimits(Limits{MaxSeries: 4, Policy: OverflowEvict}),
+		WithErrorHandler(func(error) {}),
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+	col.Set(1, []string{"ns1"}, []string{"a"}, "Pending")
+	col.Set(1, []string{"ns1"}, []string{"b"}, "Running")
+	col.Set(1, []string{"ns2"}, []string{"a"}, "Running")
+	col.Set(1, []string{"ns3"}, []string{"a"}, "Running") // evicts ns1 (3 series)
+	col.Set(1, []string{"ns4"}, []string{"a"}, "Running")
+	col.DeleteByIndex("ns2")
+	col.Set(1, []string{"ns1"}, nil, "Running") // arity error, handled
+
+	expected := `
+# HELP gaugevecset_deletes_total Number of series deleted from the set, including expired and evicted series.
+# TYPE gaugevecset_deletes_total counter
+gaugevecset_deletes_total{set="testns_subsys_self"} 4
+# HELP gaugevecset_evictions_total Number of series evicted by the set's cardinality limits.
+# TYPE gaugevecset_evictions_total counter
+gaugevecset_evictions_total{set="testns_subsys_self"} 3
+# HELP gaugevecset_groups Number of (index, group) label-values tuples tracked by the set.
+# TYPE gaugevecset_groups gauge
+gaugevecset_groups{set="testns_subsys_self"} 2
+# HELP gaugevecset_indexes Number of index label-values tuples tracked by the set.
+# TYPE gaugevecset_indexes gauge
+gaugevecset_indexes{set="testns_subsys_self"} 2
+# HELP gaugevecset_panics_avoided_total Number of errors passed to a non-panicking error handler.
+# TYPE gaugevecset_panics_avoided_total counter
+gaugevecset_panics_avoided_total{set="testns_subsys_self"} 1
+# HELP gaugevecset_series Number of series tracked by the set.
+# TYPE gaugevecset_series gauge
+gaugevecset_series{set="testns_subsys_self"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"gaugevecset_deletes_total", "gaugevecset_evictions_total", "gaugevecset_groups",
+		"gaugevecset_indexes", "gaugevecset_panics_avoided_total", "gaugevecset_series",
+	))
+	assert.Equal(t, 2, testutil.CollectAndCount(col, "testns_subsys_self"))
+}
+
+// Ensure the self metrics of several sets can be registered side by side as companion collectors
+func Test_DynamicGaugeCollector_SelfMetricsCompanion(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gauges := NewGaugeVecSet("testns", "subsys", "gauges", "help text", []string{"namespace"}, nil)
+	counters := NewCounterVecSet("testns", "subsys", "counters_total", "help text", []string{"namespace"}, nil)
+	require.NoError(t, reg.Register(gauges.SelfMetrics()))
+	require.NoError(t, reg.Register(counters.SelfMetrics()))
+
+	gauges.Set(1, []string{"ns1"}, nil)
+	counters.Inc([]string{"ns1"}, nil)
+	counters.Inc([]string{"ns2"}, nil)
+
+	expected := `
+# HELP gaugevecset_series Number of series tracked by the set.
+# TYPE gaugevecset_series gauge
+gaugevecset_series{set="testns_subsys_counters_total"} 2
+gaugevecset_series{set="testns_subsys_gauges"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "gaugevecset_series"))
+
+	// Sets without WithSelfMetrics don't export them from their own Collect
+	assert.Equal(t, 1, testutil.CollectAndCount(gauges))
+}
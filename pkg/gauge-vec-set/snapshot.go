@@ -0,0 +1,189 @@
+package gauge_vec_set
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// snapshotVersion is the version of the snapshot format written by Snapshot and accepted by Restore.
+const snapshotVersion = 1
+
+// snapshot is the JSON document written by Snapshot: the label schema of the set and all of its series.
+type snapshot struct {
+	Version     int              `json:"version"`
+	IndexLabels []string         `json:"indexLabels"`
+	GroupLabels []string         `json:"groupLabels,omitempty"`
+	ExtraLabels []string         `json:"extraLabels,omitempty"`
+	Series      []snapshotSeries `json:"series"`
+}
+
+// snapshotSeries is a single series of a snapshot.
+type snapshotSeries struct {
+	Index []string      `json:"index"`
+	Group []string      `json:"group,omitempty"`
+	Extra []string      `json:"extra,omitempty"`
+	Value snapshotValue `json:"value"`
+}
+
+// snapshotValue is a gauge value. JSON numbers cannot represent NaN and infinities, so they are encoded as the
+// strings "NaN", "+Inf" and "-Inf", as in the Prometheus text format.
+type snapshotValue float64
+
+// MarshalJSON implements json.Marshaler.
+func (v snapshotValue) MarshalJSON() ([]byte, error) {
+	f := float64(v)
+	switch {
+	case math.IsNaN(f):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(f, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(f, -1):
+		return []byte(`"-Inf"`), nil
+	}
+	return json.Marshal(f)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *snapshotValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var f float64
+		if err := json.Unmarshal(data, &f); err != nil {
+			return err
+		}
+		*v = snapshotValue(f)
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return fmt.Errorf("invalid value %q: expected a number, \"NaN\", \"+Inf\" or \"-Inf\"", s)
+	}
+	*v = snapshotValue(f)
+	return nil
+}
+
+// Snapshot writes the label schema and all series of the set to w as versioned JSON, sorted by label values, so that
+// Restore can rebuild them after a restart.
+//
+// The series are read while holding every group lock for reading, like Collect, so group transitions are captured
+// either completely or not at all.
+func (c *GaugeVecSet) Snapshot(w io.Writer) error {
+	snap := snapshot{
+		Version:     snapshotVersion,
+		IndexLabels: c.indexLabels,
+		GroupLabels: c.groupLabels,
+		ExtraLabels: c.extraLabels,
+	}
+
+	c.groupLocks.rlockAll()
+	c.mu.RLock()
+	snap.Series = make([]snapshotSeries, 0, c.series)
+	for _, groupMap := range c.indexes {
+		for _, group := range groupMap {
+			for _, s := range group {
+				indexValues, groupValues, extraValues := c.splitValues(s.values)
+				snap.Series = append(snap.Series, snapshotSeries{
+					Index: indexValues,
+					Group: groupValues,
+					Extra: extraValues,
+					Value: snapshotValue(gaugeValue(s.metric)),
+				})
+			}
+		}
+	}
+	c.mu.RUnlock()
+	c.groupLocks.runlockAll()
+
+	slices.SortFunc(snap.Series, func(a, b snapshotSeries) int {
+		return slices.Compare(
+			buildAllValues(a.Index, a.Group, a.Extra), buildAllValues(b.Index, b.Group, b.Extra),
+		)
+	})
+	return json.NewEncoder(w).Encode(snap)
+}
+
+// Restore reads a snapshot written by Snapshot from r and writes its series to the set, as Set would: the series
+// count against the limits and are stamped with the set's TTL and the current sweep generation. If the set folds
+// writes beyond its limits, the overflow series of the snapshot are written last and, like when they were folded
+// into, do not count against the limits.
+//
+// Restore is meant to run on a freshly constructed set, before it is written to; series already in the set are
+// kept, and overwritten if the snapshot holds them too. Snapshots of another version (ErrSnapshotVersion) or whose
+// index, group or extra labels differ from the set's (ErrSnapshotSchema) are rejected, and so are series the set
+// would reject, before any series is written. If the limits reject a series, the others are still written and the
+// first error is returned.
+func (c *GaugeVecSet) Restore(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("%w: got %d, expected %d", ErrSnapshotVersion, snap.Version, snapshotVersion)
+	}
+	if !slices.Equal(snap.IndexLabels, c.indexLabels) ||
+		!slices.Equal(snap.GroupLabels, c.groupLabels) ||
+		!slices.Equal(snap.ExtraLabels, c.extraLabels) {
+		return fmt.Errorf("%w: got labels %v/%v/%v, expected %v/%v/%v", ErrSnapshotSchema,
+			snap.IndexLabels, snap.GroupLabels, snap.ExtraLabels, c.indexLabels, c.groupLabels, c.extraLabels)
+	}
+	var series, overflow []snapshotSeries
+	for _, s := range snap.Series {
+		isOverflow, err := c.validateSnapshotSeries(s)
+		if err != nil {
+			return err
+		}
+		if isOverflow {
+			overflow = append(overflow, s)
+		} else {
+			series = append(series, s)
+		}
+	}
+
+	var err error
+	for _, s := range series {
+		allValues := buildAllValues(s.Index, s.Group, s.Extra)
+		setErr := c.set(float64(s.Value), serialize(s.Index), serialize(s.Group), allValues)
+		if setErr != nil && err == nil {
+			err = setErr
+		}
+	}
+	for _, s := range overflow {
+		allValues := buildAllValues(s.Index, s.Group, s.Extra)
+		ref := seriesRef{
+			indexKey:  serialize(s.Index),
+			groupKey:  serialize(s.Group),
+			fullKey:   serialize(allValues),
+			allValues: allValues,
+			folded:    true,
+		}
+		g := c.child(ref)
+		g.Set(float64(s.Value))
+		c.cacheWithKeys(ref, g)
+	}
+	return err
+}
+
+// validateSnapshotSeries validates s as a write of the set would, and reports whether it is an overflow series. The
+// extra values of overflow series are exempt from the enum, since they hold OverflowLabelValue instead of a state.
+func (c *GaugeVecSet) validateSnapshotSeries(s snapshotSeries) (isOverflow bool, err error) {
+	if err := c.validateIndexValues(s.Index); err != nil {
+		return false, err
+	}
+	if err := c.validateGroupValues(s.Group); err != nil {
+		return false, err
+	}
+	if len(s.Extra) != len(c.extraLabels) {
+		return false, arityError(ErrExtraArity, "extra", c.extraLabels, len(s.Extra))
+	}
+	isOverflow = c.isOverflow(buildAllValues(s.Index, s.Group, s.Extra))
+	if c.enum != nil && !isOverflow {
+		if err := c.enum.validate(s.Extra); err != nil {
+			return false, err
+		}
+	}
+	return isOverflow, c.validateStateValue(float64(s.Value))
+}
@@ -0,0 +1,147 @@
+package gauge_vec_set
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure Restore rebuilds the series, values and index written by Snapshot
+func Test_DynamicGaugeCollector_Snapshot(t *testing.T) {
+	for _, standalone := range []bool{false, true} {
+		options := []Option{WithEnum("phase", "Pending", "Running", "Failed")}
+		if standalone {
+			options = append(options, WithStandaloneStorage())
+		}
+		reg := prometheus.NewRegistry()
+		col := NewGaugeVecSetWithOpts(
+			prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+			[]string{"namespace"}, // index
+			[]string{"pod"},       // group
+			[]string{"phase"},     // extra
+			options...,
+		)
+		require.NoError(t, reg.Register(col))
+
+		col.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Running")
+		col.SetActiveInGroup(1, []string{"ns2"}, []string{"b"}, "Failed")
+		col.Set(math.Inf(1), []string{"ns2"}, []string{"c"}, "Pending")
+		col.Set(math.NaN(), []string{"ns2"}, []string{"d"}, "Pending")
+
+		var buf bytes.Buffer
+		require.NoError(t, col.Snapshot(&buf))
+		assert.True(t, strings.HasPrefix(buf.String(), `{"version":1,"indexLabels":["namespace"],`))
+		assert.Contains(t, buf.String(), `{"index":["ns2"],"group":["c"],"extra":["Pending"],"value":"+Inf"}`)
+
+		restoredReg := prometheus.NewRegistry()
+		restored := NewGaugeVecSetWithOpts(
+			prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+			[]string{"namespace"}, // index
+			[]string{"pod"},       // group
+			[]string{"phase"},     // extra
+			options...,
+		)
+		require.NoError(t, restoredReg.Register(restored))
+		require.NoError(t, restored.Restore(&buf))
+
+		want, err := reg.Gather()
+		require.NoError(t, err)
+		got, err := restoredReg.Gather()
+		require.NoError(t, err)
+		assert.Equal(t, want[0].String(), got[0].String())
+		assert.Equal(t, col.Indexes(), restored.Indexes())
+		assert.Equal(t, col.Groups("ns2"), restored.Groups("ns2"))
+		assert.Equal(t, 8, restored.series)
+
+		// The index is rebuilt: transitions and deletions work on restored series.
+		restored.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Pending")
+		extra, _, ok := restored.ActiveInGroup([]string{"ns1"}, "a")
+		assert.True(t, ok)
+		assert.Equal(t, []string{"Pending"}, extra)
+		assert.Equal(t, 5, restored.DeleteByIndex("ns2"))
+	}
+}
+
+// Ensure Restore writes back the overflow series of a set folding writes beyond its limits
+func Test_DynamicGaugeCollector_Snapshot_Overflow(t *testing.T) {
+	options := []Option{
+		WithEnum("phase", "Pending", "Running", "Failed"),
+		WithLimits(Limits{MaxSeriesPerIndex: 3, Policy: OverflowFold}),
+	}
+	reg := prometheus.NewRegistry()
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		options...,
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Running")
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"b"}, "Pending")
+	col.SetActiveInGroup(1, []string{"ns2"}, []string{"c"}, "Failed")
+
+	var buf bytes.Buffer
+	require.NoError(t, col.Snapshot(&buf))
+	assert.Contains(t, buf.String(),
+		`{"index":["ns1"],"group":["__overflow__"],"extra":["__overflow__"],"value":1}`)
+
+	restoredReg := prometheus.NewRegistry()
+	restored := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		options...,
+	)
+	require.NoError(t, restoredReg.Register(restored))
+	require.NoError(t, restored.Restore(&buf))
+
+	want, err := reg.Gather()
+	require.NoError(t, err)
+	got, err := restoredReg.Gather()
+	require.NoError(t, err)
+	assert.Equal(t, want[0].String(), got[0].String())
+	assert.Equal(t, 7, restored.series)
+	assert.Equal(t, LimitStats{}, restored.LimitStats())
+}
+
+// Ensure Restore rejects snapshots of another version or label schema, and invalid series, without writing anything
+func Test_DynamicGaugeCollector_Snapshot_Invalid(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithEnum("phase", "Pending", "Running", "Failed"),
+	)
+
+	err := col.Restore(strings.NewReader(`{"version":2,"indexLabels":["namespace"],"series":[]}`))
+	assert.ErrorIs(t, err, ErrSnapshotVersion)
+
+	other := NewGaugeVecSet("testns", "subsys", "phase", "help text", []string{"namespace"}, []string{"pod"}, "status")
+	other.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+	var buf bytes.Buffer
+	require.NoError(t, other.Snapshot(&buf))
+	assert.ErrorIs(t, col.Restore(&buf), ErrSnapshotSchema)
+
+	err = col.Restore(strings.NewReader(`{"version":1,"indexLabels":["namespace"],"groupLabels":["pod"],` +
+		`"extraLabels":["phase"],"series":[` +
+		`{"index":["ns1"],"group":["a"],"extra":["Running"],"value":1},` +
+		`{"index":["ns1"],"group":["b"],"extra":["Unknown"],"value":1}]}`))
+	assert.ErrorIs(t, err, ErrUnknownState)
+
+	err = col.Restore(strings.NewReader(`{"version":1,"indexLabels":["namespace"],"series":[{"value":"1"}]}`))
+	assert.Error(t, err)
+	assert.Error(t, col.Restore(strings.NewReader(`not json`)))
+
+	assert.Equal(t, 0, testutil.CollectAndCount(col))
+	assert.Empty(t, col.indexes)
+}
@@ -0,0 +1,138 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ownedVec is implemented by metric vectors that do not keep their children: the nested index of the set is the
+// only place a child lives, and the set emits it as a const metric at collection time.
+type ownedVec[T any] interface {
+	metricVec[T]
+	// constMetric freezes the current value of child, the series with the given label values.
+	constMetric(child T, values []string) prometheus.Metric
+}
+
+// standaloneGaugeVec is the ownedVec backing GaugeVecSets created with WithStandaloneStorage.
+//
+// WithLabelValues returns a new gauge on every call; VecSet resolves existing series from its index instead.
+// Dropping the GaugeVec avoids storing every series twice, along with the label pairs and hashes it keeps per
+// child.
+type standaloneGaugeVec struct {
+	desc *prometheus.Desc
+}
+
+// newStandaloneGaugeVec returns a standaloneGaugeVec for the series described by opts and labels.
+func newStandaloneGaugeVec(opts prometheus.GaugeOpts, labels []string) *standaloneGaugeVec {
+	return &standaloneGaugeVec{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name), opts.Help, labels, opts.ConstLabels,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (v *standaloneGaugeVec) Describe(ch chan<- *prometheus.Desc) {
+	ch <- v.desc
+}
+
+// Collect implements prometheus.Collector. It emits nothing: the children are collected from the set's index.
+func (v *standaloneGaugeVec) Collect(chan<- prometheus.Metric) {}
+
+// WithLabelValues returns a new gauge for lvs. Like GaugeVec.WithLabelValues, it panics on invalid label values.
+func (v *standaloneGaugeVec) WithLabelValues(lvs ...string) prometheus.Gauge {
+	for _, value := range lvs {
+		if !utf8.ValidString(value) {
+			panic(fmt.Errorf("label value %q is not valid UTF-8", value))
+		}
+	}
+	return &standaloneGauge{desc: v.desc, values: lvs}
+}
+
+// DeleteLabelValues implements metricVec. Series are deleted once they are removed from the set's index.
+func (v *standaloneGaugeVec) DeleteLabelValues(...string) bool {
+	return true
+}
+
+// constMetric implements ownedVec.
+func (v *standaloneGaugeVec) constMetric(child prometheus.Gauge, values []string) prometheus.Metric {
+	return prometheus.MustNewConstMetric(v.desc, prometheus.GaugeValue, child.(*standaloneGauge).value(), values...)
+}
+
+// standaloneGauge is a prometheus.Gauge that only stores its value; its label values are shared with the index.
+type standaloneGauge struct {
+	valBits atomic.Uint64
+	desc    *prometheus.Desc
+	values  []string
+}
+
+// value returns the current value of the gauge.
+func (g *standaloneGauge) value() float64 {
+	return math.Float64frombits(g.valBits.Load())
+}
+
+// Desc implements prometheus.Metric.
+func (g *standaloneGauge) Desc() *prometheus.Desc {
+	return g.desc
+}
+
+// Write implements prometheus.Metric.
+func (g *standaloneGauge) Write(out *dto.Metric) error {
+	m, err := prometheus.NewConstMetric(g.desc, prometheus.GaugeValue, g.value(), g.values...)
+	if err != nil {
+		return err
+	}
+	return m.Write(out)
+}
+
+// Describe implements prometheus.Collector.
+func (g *standaloneGauge) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+// Collect implements prometheus.Collector.
+func (g *standaloneGauge) Collect(ch chan<- prometheus.Metric) {
+	ch <- g
+}
+
+// Set implements prometheus.Gauge.
+func (g *standaloneGauge) Set(value float64) {
+	g.valBits.Store(math.Float64bits(value))
+}
+
+// SetToCurrentTime implements prometheus.Gauge.
+func (g *standaloneGauge) SetToCurrentTime() {
+	g.Set(float64(time.Now().UnixNano()) / 1e9)
+}
+
+// Inc implements prometheus.Gauge.
+func (g *standaloneGauge) Inc() {
+	g.Add(1)
+}
+
+// Dec implements prometheus.Gauge.
+func (g *standaloneGauge) Dec() {
+	g.Add(-1)
+}
+
+// Add implements prometheus.Gauge.
+func (g *standaloneGauge) Add(value float64) {
+	for {
+		oldBits := g.valBits.Load()
+		newBits := math.Float64bits(math.Float64frombits(oldBits) + value)
+		if g.valBits.CompareAndSwap(oldBits, newBits) {
+			return
+		}
+	}
+}
+
+// Sub implements prometheus.Gauge.
+func (g *standaloneGauge) Sub(value float64) {
+	g.Add(-value)
+}
@@ -0,0 +1,111 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure both storage backends export the same series for the same operations
+func Test_DynamicGaugeCollector_StandaloneStorage(t *testing.T) {
+	for _, standalone := range []bool{false, true} {
+		name := "gauge_vec"
+		if standalone {
+			name = "standalone"
+		}
+		t.Run(name, func(t *testing.T) {
+			options := []Option{WithEnum("phase", "Pending", "Running", "Failed")}
+			if standalone {
+				options = append(options, WithStandaloneStorage())
+			}
+			reg := prometheus.NewRegistry()
+			col := NewGaugeVecSetWithOpts(
+				prometheus.GaugeOpts{
+					Namespace:   "testns",
+					Subsystem:   "subsys",
+					Name:        "phase",
+					Help:        "help text",
+					ConstLabels: prometheus.Labels{"cluster": "eu1"},
+				},
+				[]string{"namespace"}, // index
+				[]string{"pod"},       // group
+				[]string{"phase"},     // extra
+				options...,
+			)
+			require.NoError(t, reg.Register(col))
+
+			col.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Running")
+			col.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Failed")
+			col.Set(3, []string{"ns1"}, []string{"b"}, "Pending")
+			col.Add(2, []string{"ns1"}, []string{"b"}, "Pending")
+			col.Inc([]string{"ns2"}, []string{"c"}, "Running")
+			col.SetGroup(5, []string{"ns3"}, []string{"d"}, "Pending")
+			col.SetGroup(7, []string{"ns3"}, []string{"d"}, "Running")
+			col.WithLabelValues([]string{"ns4"}, []string{"e"}, "Running").Set(4)
+			assert.Equal(t, 1, col.DeleteByIndex("ns2"))
+
+			expected := `
+# HELP testns_subsys_phase help text
+# TYPE testns_subsys_phase gauge
+testns_subsys_phase{cluster="eu1",namespace="ns1",phase="Failed",pod="a"} 1
+testns_subsys_phase{cluster="eu1",namespace="ns1",phase="Pending",pod="a"} 0
+testns_subsys_phase{cluster="eu1",namespace="ns1",phase="Pending",pod="b"} 5
+testns_subsys_phase{cluster="eu1",namespace="ns1",phase="Running",pod="a"} 0
+testns_subsys_phase{cluster="eu1",namespace="ns3",phase="Running",pod="d"} 7
+testns_subsys_phase{cluster="eu1",namespace="ns4",phase="Running",pod="e"} 4
+`
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "testns_subsys_phase"))
+
+			value, ok := col.Get([]string{"ns1"}, []string{"b"}, "Pending")
+			assert.True(t, ok)
+			assert.Equal(t, 5.0, value)
+			extra, value, ok := col.ActiveInGroup([]string{"ns1"}, "a")
+			assert.True(t, ok)
+			assert.Equal(t, []string{"Failed"}, extra)
+			assert.Equal(t, 1.0, value)
+
+			assert.Equal(t, 6, col.DeleteByIndexPrefix())
+			assert.Equal(t, 0, testutil.CollectAndCount(col, "testns_subsys_phase"))
+		})
+	}
+}
+
+// Ensure series re-created after a deletion start from zero with the standalone backend
+func Test_DynamicGaugeCollector_StandaloneStorage_Recreate(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithStandaloneStorage(),
+	)
+
+	col.Add(2, []string{"ns1"}, []string{"a"}, "Running")
+	col.DeleteByGroup([]string{"ns1"}, "a")
+	col.Add(1, []string{"ns1"}, []string{"a"}, "Running")
+
+	value, ok := col.Get([]string{"ns1"}, []string{"a"}, "Running")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, value)
+}
+
+// Ensure the standalone backend rejects invalid label values on write, like GaugeVec, rather than on scrape
+func Test_DynamicGaugeCollector_StandaloneStorage_InvalidLabelValue(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "phase", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithStandaloneStorage(),
+	)
+	require.NoError(t, reg.Register(col))
+
+	assert.Panics(t, func() { col.Set(1, []string{"ns\xff"}, []string{"a"}, "Running") })
+	_, err := reg.Gather()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,51 @@
+package gauge_vec_set
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SummaryVecSet wraps a Prometheus SummaryVec with the index/group bookkeeping of VecSet,
+// so per-object summaries can be removed in bulk with DeleteByIndex, DeleteByIndexPrefix and DeleteByGroup.
+type SummaryVecSet struct {
+	VecSet[prometheus.Observer]
+}
+
+// NewSummaryVecSet constructs a SummaryVecSet from the given options.
+// The label parameters have the same meaning as for NewGaugeVecSet.
+//
+// Returns an *unregistered* collector; register it with a Prometheus registry yourself.
+func NewSummaryVecSet(
+	opts prometheus.SummaryOpts,
+	indexLabels []string,
+	groupLabels []string,
+	extraLabels ...string,
+) *SummaryVecSet {
+	validateLowercaseUnderscore(opts.Namespace)
+	validateLowercaseUnderscore(opts.Subsystem)
+	validateLowercaseUnderscore(opts.Name)
+
+	allLabels := validateLabels(indexLabels, groupLabels, extraLabels)
+
+	sv := prometheus.NewSummaryVec(opts, allLabels)
+
+	return &SummaryVecSet{
+		VecSet: newVecSet[prometheus.Observer](
+			prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name), sv, prometheus.NewSummary(opts),
+			indexLabels, groupLabels, extraLabels,
+		),
+	}
+}
+
+// Observe adds a single observation to the summary identified by (index, group, extra).
+func (c *SummaryVecSet) Observe(value float64, indexValues []string, groupValues []string, extraValues ...string) {
+	if err := c.TryObserve(value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TryObserve is like Observe but returns an error instead of invoking the error handler.
+func (c *SummaryVecSet) TryObserve(
+	value float64, indexValues []string, groupValues []string, extraValues ...string,
+) error {
+	return observe(&c.VecSet, value, indexValues, groupValues, extraValues)
+}
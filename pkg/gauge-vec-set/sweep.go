@@ -0,0 +1,42 @@
+package gauge_vec_set
+
+// BeginSweep starts a new sweep generation and returns it. Every series written from now on (Set, SetGroup,
+// SetActiveInGroup, Add, WithLabelValues, ...) is stamped with the generation; EndSweep then deletes the series that
+// were not written since.
+//
+// Sweeps implement full resyncs: begin a sweep, write every series that still exists (e.g. while processing a full
+// relist) and end the sweep to delete the rest. Writes through a child metric returned by WithLabelValues do not
+// stamp the series; only the WithLabelValues call itself does.
+//
+// Example:
+//
+//	gen := col.BeginSweep()
+//	for _, pod := range pods {
+//		col.SetActiveInGroup(1, []string{pod.Namespace}, []string{pod.Name}, string(pod.Status.Phase))
+//	}
+//	col.EndSweep(gen)
+func (c *VecSet[T]) BeginSweep() (generation uint64) {
+	return c.generation.Add(1)
+}
+
+// EndSweep deletes every series that was not written since BeginSweep returned generation, pruning groups and
+// indexes left empty. Returns the number of deleted series.
+//
+// Series written in a later generation are kept, so overlapping sweeps may end in any order.
+func (c *VecSet[T]) EndSweep(generation uint64) (deleted int) {
+	c.mu.Lock()
+	var stale [][]string
+	for indexKey, groupMap := range c.indexes {
+		for groupKey, group := range groupMap {
+			for fullKey, s := range group {
+				if s.generation < generation {
+					c.uncacheLocked(indexKey, groupKey, fullKey)
+					stale = append(stale, s.values)
+				}
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	return c.deleteSeries(stale)
+}
@@ -0,0 +1,45 @@
+package gauge_vec_set
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensure EndSweep deletes the series not written since BeginSweep and prunes empty groups and indexes
+func Test_DynamicGaugeCollector_Sweep(t *testing.T) {
+	col := NewGaugeVecSet("testns", "subsys", "phase", "help text", []string{"namespace"}, []string{"pod"}, "phase")
+
+	col.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"b"}, "Running")
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"b"}, "Failed")
+	col.Set(1, []string{"ns2"}, []string{"c"}, "Running")
+
+	gen := col.BeginSweep()
+	col.Set(2, []string{"ns1"}, []string{"a"}, "Running")
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"b"}, "Failed") // touches the zeroed sibling too
+	assert.Equal(t, 1, col.EndSweep(gen))
+
+	assert.Equal(t, [][]string{{"ns1"}}, col.Indexes())
+	assert.Equal(t, 3, testutil.CollectAndCount(col))
+
+	// Nothing written since the new sweep began: everything goes.
+	assert.Equal(t, 3, col.EndSweep(col.BeginSweep()))
+	assert.Empty(t, col.Indexes())
+	assert.Equal(t, 0, testutil.CollectAndCount(col))
+}
+
+// Ensure series written in a later generation survive the end of an earlier sweep
+func Test_DynamicGaugeCollector_Sweep_Overlapping(t *testing.T) {
+	col := NewGaugeVecSet("testns", "subsys", "phase", "help text", []string{"namespace"}, []string{"pod"}, "phase")
+
+	first := col.BeginSweep()
+	col.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+	second := col.BeginSweep()
+	col.Set(1, []string{"ns1"}, []string{"b"}, "Running")
+
+	assert.Equal(t, 0, col.EndSweep(first))
+	assert.Equal(t, 1, col.EndSweep(second))
+	assert.Equal(t, [][]string{{"b"}}, col.Groups("ns1"))
+}
@@ -0,0 +1,122 @@
+package gauge_vec_set
+
+import (
+	"context"
+	"time"
+)
+
+// expiry records when a series was last written and how long it may live without another write.
+type expiry struct {
+	indexKey string
+	groupKey string
+	updated  time.Time
+	ttl      time.Duration
+}
+
+// stampLocked records the write time of fullKey and the ttl it lives for without another write. A non-positive ttl
+// pins the series, so it never expires even if the set has a default TTL; without a default TTL, fullKey is simply
+// no longer tracked. The caller must hold the write lock.
+func (c *VecSet[T]) stampLocked(indexKey, groupKey, fullKey string, ttl time.Duration) {
+	if ttl <= 0 {
+		if c.ttl <= 0 {
+			delete(c.expiries, fullKey)
+			return
+		}
+		ttl = 0
+	}
+	c.expiries[fullKey] = expiry{indexKey: indexKey, groupKey: groupKey, updated: c.now(), ttl: ttl}
+}
+
+// touchGroup refreshes the write time and the sweep generation of every series in (indexKey, groupKey). Used when a
+// write affects the whole group, e.g. SetActiveInGroup zeroing the siblings. Every series keeps the TTL it was last
+// written with (e.g. by SetWithTTL); the set's default TTL only applies to series not stamped yet.
+func (c *VecSet[T]) touchGroup(indexKey, groupKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	generation := c.generation.Load()
+	if len(c.expiries) == 0 && c.ttl <= 0 && generation == 0 {
+		return
+	}
+
+	group := c.indexes[indexKey][groupKey]
+	for fullKey, s := range group {
+		if e, ok := c.expiries[fullKey]; ok {
+			c.stampLocked(indexKey, groupKey, fullKey, e.ttl)
+		} else if c.ttl > 0 {
+			c.stampLocked(indexKey, groupKey, fullKey, c.ttl)
+		}
+		s.generation = generation
+		group[fullKey] = s
+	}
+}
+
+// ExpireStale removes every series whose TTL elapsed since its last write, from both the metric vector and the
+// index, pruning groups and indexes left empty. Returns the number of expired series.
+//
+// Series are only subject to expiry if they were written with a TTL, either the set's default (WithTTL) or a
+// per-call one (e.g. SetWithTTL). StartJanitor calls ExpireStale periodically.
+func (c *VecSet[T]) ExpireStale() (expired int) {
+	c.mu.Lock()
+	now := c.now()
+	var stale [][]string
+	for fullKey, e := range c.expiries {
+		if e.ttl <= 0 || now.Sub(e.updated) < e.ttl {
+			continue
+		}
+		if s, ok := c.uncacheLocked(e.indexKey, e.groupKey, fullKey); ok {
+			stale = append(stale, s.values)
+		}
+	}
+	c.mu.Unlock()
+
+	return c.deleteSeries(stale)
+}
+
+// StartJanitor starts a goroutine that calls ExpireStale every interval until ctx is done.
+func (c *VecSet[T]) StartJanitor(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.ExpireStale()
+			}
+		}
+	}()
+}
+
+// SetWithTTL is like Set, but the series expires once ttl elapsed without another write, overriding the set's
+// default TTL for this write. A non-positive ttl makes the series never expire.
+func (c *GaugeVecSet) SetWithTTL(
+	ttl time.Duration, value float64, indexValues []string, groupValues []string, extraValues ...string,
+) {
+	if err := c.TrySetWithTTL(ttl, value, indexValues, groupValues, extraValues...); err != nil {
+		c.handleError(err)
+	}
+}
+
+// TrySetWithTTL is like SetWithTTL but returns an error instead of invoking the error handler.
+func (c *GaugeVecSet) TrySetWithTTL(
+	ttl time.Duration, value float64, indexValues []string, groupValues []string, extraValues ...string,
+) error {
+	if err := c.validateValues(indexValues, groupValues, extraValues); err != nil {
+		return err
+	}
+	if err := c.validateStateValue(value); err != nil {
+		return err
+	}
+
+	ref, err := c.admit(serialize(indexValues), serialize(groupValues), buildAllValues(indexValues, groupValues, extraValues))
+	if err != nil {
+		return err
+	}
+	g := c.child(ref)
+	g.Set(value)
+	c.cacheWithTTL(ref, g, ttl)
+	return nil
+}
@@ -0,0 +1,152 @@
+package gauge_vec_set
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for TTL tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Ensure series expire after the set's TTL and are pruned from the index
+func Test_DynamicGaugeCollector_TTL(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ttl", Help: "help text"},
+		[]string{"namespace"}, // index
+		[]string{"pod"},       // group
+		[]string{"phase"},     // extra
+		WithTTL(time.Minute),
+		WithClock(clock.Now),
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Set(1, []string{"ns1"}, []string{"a"}, "Running")
+	col.Set(1, []string{"ns1"}, []string{"b"}, "Running")
+	col.SetWithTTL(0, 1, []string{"ns2"}, []string{"c"}, "Running")         // never expires
+	col.SetWithTTL(time.Hour, 1, []string{"ns3"}, []string{"d"}, "Running") // expires later
+
+	clock.Advance(45 * time.Second)
+	col.Set(2, []string{"ns1"}, []string{"b"}, "Running") // refreshed
+	assert.Equal(t, 0, col.ExpireStale())
+
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, 1, col.ExpireStale())
+
+	want := `
+# HELP testns_subsys_ttl help text
+# TYPE testns_subsys_ttl gauge
+testns_subsys_ttl{namespace="ns1",phase="Running",pod="b"} 2
+testns_subsys_ttl{namespace="ns2",phase="Running",pod="c"} 1
+testns_subsys_ttl{namespace="ns3",phase="Running",pod="d"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_ttl"))
+	assert.Equal(t, [][]string{{"b"}}, col.Groups("ns1"))
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, 2, col.ExpireStale())
+	assert.Equal(t, [][]string{{"ns2"}}, col.Indexes())
+	assert.Len(t, col.expiries, 1) // the pinned series
+
+	// Deleted series are no longer tracked for expiry
+	col.Set(1, []string{"ns4"}, []string{"e"}, "Running")
+	assert.Equal(t, 1, col.DeleteByIndex("ns4"))
+	assert.Len(t, col.expiries, 1)
+}
+
+// Ensure SetActiveInGroup keeps the whole group alive
+func Test_DynamicGaugeCollector_TTL_SetActiveInGroup(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ttl_group", Help: "help text"},
+		[]string{"namespace"}, []string{"pod"}, []string{"phase"},
+		WithTTL(time.Minute),
+		WithClock(clock.Now),
+	)
+
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Pending")
+	clock.Advance(45 * time.Second)
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Running")
+	clock.Advance(30 * time.Second)
+
+	assert.Equal(t, 0, col.ExpireStale())
+	assert.Len(t, col.GroupValues([]string{"ns1"}, "a"), 2)
+}
+
+// Ensure SetActiveInGroup refreshes the siblings with the TTL they were written with
+func Test_DynamicGaugeCollector_TTL_SetActiveInGroup_KeepsTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ttl_group", Help: "help text"},
+		[]string{"namespace"}, []string{"pod"}, []string{"phase"},
+		WithTTL(time.Minute),
+		WithClock(clock.Now),
+	)
+
+	col.SetWithTTL(0, 1, []string{"ns1"}, []string{"a"}, "Pending")        // never expires
+	col.SetWithTTL(time.Hour, 1, []string{"ns1"}, []string{"a"}, "Failed") // expires later
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Running")
+
+	clock.Advance(2 * time.Minute)
+	assert.Equal(t, 1, col.ExpireStale())
+	clock.Advance(2 * time.Hour)
+	assert.Equal(t, 1, col.ExpireStale())
+	assert.Equal(t, []Series{{GroupValues: []string{"a"}, ExtraValues: []string{"Pending"}, Value: 0}},
+		col.GroupValues([]string{"ns1"}, "a"))
+
+	// Without a default TTL, per-call TTLs are refreshed too.
+	col = NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ttl_group", Help: "help text"},
+		[]string{"namespace"}, []string{"pod"}, []string{"phase"},
+		WithClock(clock.Now),
+	)
+	col.SetWithTTL(time.Minute, 1, []string{"ns1"}, []string{"a"}, "Pending")
+	clock.Advance(45 * time.Second)
+	col.SetActiveInGroup(1, []string{"ns1"}, []string{"a"}, "Running")
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, 0, col.ExpireStale())
+}
+
+func Test_DynamicGaugeCollector_TTL_Janitor(t *testing.T) {
+	col := NewGaugeVecSetWithOpts(
+		prometheus.GaugeOpts{Namespace: "testns", Subsystem: "subsys", Name: "ttl_janitor", Help: "help text"},
+		[]string{"namespace"}, nil, nil,
+		WithTTL(time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	col.StartJanitor(ctx, 5*time.Millisecond)
+
+	col.Set(1, []string{"ns1"}, nil)
+	assert.Eventually(t, func() bool {
+		return testutil.CollectAndCount(col) == 0 && len(col.Indexes()) == 0
+	}, time.Second, 5*time.Millisecond)
+}
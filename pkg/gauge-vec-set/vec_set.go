@@ -0,0 +1,637 @@
+package gauge_vec_set
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricVec is the subset of the prometheus *Vec types (GaugeVec, CounterVec, HistogramVec, SummaryVec)
+// used by VecSet. T is the type of the child metric returned by WithLabelValues.
+type metricVec[T any] interface {
+	prometheus.Collector
+	WithLabelValues(lvs ...string) T
+	DeleteLabelValues(lvs ...string) bool
+}
+
+// seriesRef identifies the series a write goes to, by its label values and its keys in the nested index.
+type seriesRef struct {
+	indexKey  string
+	groupKey  string
+	fullKey   string
+	allValues []string
+	// folded is set if the write was redirected to a sentinel series by OverflowFold.
+	folded bool
+}
+
+// indexedSeries is a series tracked in the nested index: its child metric, resolved once when the series is
+// written, and its label values (index + group + extra), so that zeroing and deleting it needs neither
+// deserialization nor a lookup in the metric vector.
+type indexedSeries[T any] struct {
+	metric T
+	values []string
+	// generation is the sweep generation of the last write; see BeginSweep.
+	generation uint64
+}
+
+// VecSet wraps a Prometheus metric vector and keeps a 3-level index:
+//
+//	indexKey -> groupKey -> fullKey -> series
+//
+// Label order in the metric is:
+//
+//	allLabels = indexLabels + groupLabels + extraLabels
+//
+// and label values follow the same order for all operations.
+//
+// VecSet implements the index bookkeeping and the bulk deletions shared by GaugeVecSet, CounterVecSet,
+// HistogramVecSet and SummaryVecSet. T is the child metric type of the wrapped vector
+// (e.g. prometheus.Counter for a CounterVec).
+type VecSet[T any] struct {
+	fqName string // fully-qualified metric name
+	metric metricVec[T]
+	// owned is metric if its children live in the nested index only (see ownedVec), nil otherwise.
+	owned ownedVec[T]
+	// discarded is the child WithLabelValues returns for rejected writes. It is neither indexed nor exported, so
+	// writes to it are dropped.
+	discarded T
+
+	indexLabels []string // labels that define the deletion index (required; order matters)
+	groupLabels []string // labels that define a mutually-exclusive group (optional; order matters)
+	extraLabels []string // additional dynamic labels not used for grouping (optional; order matters)
+
+	// Nested index: indexKey -> groupKey -> fullKey -> series
+	indexes map[string]map[string]map[string]indexedSeries[T]
+	// Prefix tree over index values, pointing at the indexKeys of the nested index.
+	indexTrie *indexTrie
+	// Secondary indexes by label name (optional; see AddSecondaryIndex).
+	secondary map[string]*secondaryIndex
+
+	// interning shares one copy of every label value and group key across series; see SetLabelInterning.
+	interning bool
+
+	// enum restricts one extra label to a declared set of states (optional).
+	enum *enumLabel
+
+	// ttl is the default time-to-live of written series; zero disables expiry.
+	ttl time.Duration
+	// now is the clock used to stamp writes (time.Now unless injected).
+	now func() time.Time
+	// Last update of every series written with a TTL: fullKey -> expiry
+	expiries map[string]expiry
+
+	// generation is the current sweep generation, stamped on every written series; see BeginSweep.
+	generation atomic.Uint64
+
+	// limits caps the number of series; see Limits.
+	limits Limits
+	// series is the number of series in the nested index.
+	series int
+	// Recency of writes, tracked for OverflowEvict only: a logical clock and the tick of the last write to
+	// every index (indexKey -> tick) and series (fullKey -> tick).
+	tick        uint64
+	indexTicks  map[string]uint64
+	seriesTicks map[string]uint64
+	// Writes rejected or folded and series evicted by the limits.
+	rejected atomic.Uint64
+	evicted  atomic.Uint64
+	folded   atomic.Uint64
+	// Series deleted from the metric vector and errors passed to a non-default error handler.
+	deletes atomic.Uint64
+	handled atomic.Uint64
+	// self exports the metrics above from Describe and Collect (optional; see WithSelfMetrics).
+	self prometheus.Collector
+
+	// errorHandler receives errors from the non-Try operations; nil means PanicErrorHandler.
+	errorHandler ErrorHandler
+
+	// exclusive is set for sets performing exclusive group writes, whose Collect freezes the values under the group
+	// locks; see Collect.
+	exclusive bool
+	// groupLocks serializes exclusive writes per (index, group); see groupLocks.
+	groupLocks groupLocks
+	mu         sync.RWMutex
+}
+
+// validateLowercaseUnderscore panics if the input contains any character
+// that is not a lowercase letter, number or underscore.
+func validateLowercaseUnderscore(input string) {
+	invalidCharPattern := regexp.MustCompile(`[^a-z0-9_]`)
+	if invalidCharPattern.MatchString(input) {
+		panic(
+			fmt.Errorf(
+				"NewGaugeVecSet: %q contains characters other than lowercase letters and underscores", input,
+			),
+		)
+	}
+	if strings.HasSuffix(input, "_") {
+		panic(
+			fmt.Errorf(
+				"NewGaugeVecSet: %q must not end with an underscore", input,
+			),
+		)
+	}
+}
+
+// validateLabels panics if no index label is given or if a label is used more than once,
+// and returns all labels in the canonical order: index + group + extra.
+func validateLabels(indexLabels, groupLabels, extraLabels []string) []string {
+	if len(indexLabels) == 0 {
+		panic("NewMultiIndexGaugeCollector: at least one index label is required")
+	}
+	allLabels := buildAllValues(indexLabels, groupLabels, extraLabels)
+
+	// Validate that all labels are unique
+	seen := make(map[string]struct{}, len(allLabels))
+	for _, label := range allLabels {
+		if _, exists := seen[label]; exists {
+			panic(
+				fmt.Sprintf(
+					"GaugeVecSet: duplicate label %q detected across index/group/extra labels", label),
+			)
+		}
+		seen[label] = struct{}{}
+	}
+	return allLabels
+}
+
+// newVecSet wraps metric, whose labels must be indexLabels + groupLabels + extraLabels, in a VecSet. discarded is an
+// unregistered metric of the same kind, receiving the writes the set rejects.
+func newVecSet[T any](
+	fqName string, metric metricVec[T], discarded T, indexLabels, groupLabels, extraLabels []string,
+) VecSet[T] {
+	owned, _ := metric.(ownedVec[T])
+	return VecSet[T]{
+		fqName:      fqName,
+		metric:      metric,
+		owned:       owned,
+		discarded:   discarded,
+		indexLabels: indexLabels,
+		groupLabels: groupLabels,
+		extraLabels: extraLabels,
+		indexes:     make(map[string]map[string]map[string]indexedSeries[T]),
+		indexTrie:   newIndexTrie(),
+		now:         time.Now,
+		expiries:    make(map[string]expiry),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *VecSet[T]) Describe(ch chan<- *prometheus.Desc) {
+	c.metric.Describe(ch)
+	if c.self != nil {
+		c.self.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+//
+// Sets with exclusive group writes (the gauge sets) read the values of the metric vector while holding every group
+// lock for reading, so group transitions (SetActiveInGroup, SetGroup, DeleteByGroup, ...), which hold the lock of
+// their group, are observed either completely or not at all. Exclusive writers stall while a scrape reads the values.
+// The other sets have no transitions to protect and collect the metric vector directly, without taking any lock.
+func (c *VecSet[T]) Collect(ch chan<- prometheus.Metric) {
+	if c.exclusive {
+		var metrics []prometheus.Metric
+		c.groupLocks.rlockAll()
+		if c.owned != nil {
+			metrics = c.constMetricsLocked()
+		} else {
+			metrics = c.freezeLocked()
+		}
+		c.groupLocks.runlockAll()
+
+		for _, m := range metrics {
+			ch <- m
+		}
+	} else {
+		c.metric.Collect(ch)
+	}
+
+	if c.self != nil {
+		c.self.Collect(ch)
+	}
+}
+
+// WithLabelValues returns the child metric for (index, group, extra) and records it in the index,
+// so it can later be removed with DeleteByIndex or DeleteByGroup.
+//
+// If the values are invalid or rejected by the limits and the error handler does not panic, the returned child is
+// neither indexed nor exported, so writes to it are dropped.
+func (c *VecSet[T]) WithLabelValues(indexValues []string, groupValues []string, extraValues ...string) T {
+	m, err := c.TryWithLabelValues(indexValues, groupValues, extraValues...)
+	if err != nil {
+		c.handleError(err)
+		return c.discarded
+	}
+	return m
+}
+
+// TryWithLabelValues is like WithLabelValues but returns an error instead of invoking the error handler.
+func (c *VecSet[T]) TryWithLabelValues(indexValues []string, groupValues []string, extraValues ...string) (T, error) {
+	if err := c.validateValues(indexValues, groupValues, extraValues); err != nil {
+		var zero T
+		return zero, err
+	}
+	ref, err := c.admit(serialize(indexValues), serialize(groupValues), buildAllValues(indexValues, groupValues, extraValues))
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	m := c.child(ref)
+	c.cacheWithKeys(ref, m)
+	return m, nil
+}
+
+// child returns the child metric a write to ref goes through.
+// Sets owning their series resolve it from the nested index, inserting it if missing, so concurrent writers of a
+// new series share one child; the others resolve it from the metric vector.
+//
+// The label values of new series are interned in place before the child is created, if enabled.
+func (c *VecSet[T]) child(ref seriesRef) T {
+	if c.owned == nil {
+		if c.interning {
+			c.mu.RLock()
+			indexed := c.indexedLocked(ref)
+			c.mu.RUnlock()
+			if !indexed {
+				internValues(ref.allValues)
+			}
+		}
+		return c.metric.WithLabelValues(ref.allValues...)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, ok := c.indexes[ref.indexKey][ref.groupKey][ref.fullKey]; ok {
+		return s.metric
+	}
+	if c.interning {
+		internValues(ref.allValues)
+	}
+	m := c.metric.WithLabelValues(ref.allValues...)
+	c.insertLocked(ref, m)
+	return m
+}
+
+// detachIndex removes indexKey from the index and returns the label values of all series that were cached under
+// it. The series are deleted from the metric vector afterwards, without holding the lock.
+//
+// This does not exclude writers of the detached series: a write racing with the deletion may cache the series again
+// before it is deleted from the vector. Readers then observe a series that is not exported until its next write,
+// which re-creates it in the vector. Sets owning their series (WithStandaloneStorage) are not affected.
+func (c *VecSet[T]) detachIndex(indexKey string) [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.detachIndexLocked(indexKey)
+}
+
+// detachIndexLocked is detachIndex for callers already holding the write lock.
+func (c *VecSet[T]) detachIndexLocked(indexKey string) [][]string {
+	var detached [][]string
+	for _, group := range c.indexes[indexKey] {
+		for fullKey, s := range group {
+			detached = append(detached, s.values)
+			c.forgetLocked(fullKey, s.values)
+		}
+	}
+	c.removeIndexLocked(indexKey)
+
+	return detached
+}
+
+// detachIndexPrefix removes all indexes matching the given index prefix and returns
+// the label values of all series that were cached under them.
+func (c *VecSet[T]) detachIndexPrefix(prefix []string) [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var detached [][]string
+	for _, indexKey := range c.indexTrie.collect(prefix) {
+		detached = append(detached, c.detachIndexLocked(indexKey)...)
+	}
+
+	return detached
+}
+
+// detachGroup removes (indexKey, groupKey) from the index, pruning the index if it becomes empty,
+// and returns the label values of all series that were cached under it.
+func (c *VecSet[T]) detachGroup(indexKey, groupKey string) [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.detachGroupLocked(indexKey, groupKey)
+}
+
+// detachGroupLocked is detachGroup for callers already holding the write lock.
+func (c *VecSet[T]) detachGroupLocked(indexKey, groupKey string) [][]string {
+	groupMap, ok := c.indexes[indexKey]
+	if !ok {
+		return nil
+	}
+	group, ok := groupMap[groupKey]
+	if !ok {
+		return nil
+	}
+	detached := make([][]string, 0, len(group))
+	for fullKey, s := range group {
+		detached = append(detached, s.values)
+		c.forgetLocked(fullKey, s.values)
+	}
+	delete(groupMap, groupKey)
+	if len(groupMap) == 0 {
+		c.removeIndexLocked(indexKey)
+	}
+	return detached
+}
+
+// deleteSeries deletes the series identified by the given label values from the metric vector.
+// Returns the number of deleted series.
+func (c *VecSet[T]) deleteSeries(series [][]string) (deleted int) {
+	for _, values := range series {
+		if c.metric.DeleteLabelValues(values...) {
+			deleted++
+		}
+	}
+	c.deletes.Add(uint64(deleted))
+	return deleted
+}
+
+// siblingsLocked returns the series under (indexKey, groupKey) other than fullKey.
+// The caller must hold the lock.
+func (c *VecSet[T]) siblingsLocked(indexKey, groupKey, fullKey string) []indexedSeries[T] {
+	group := c.indexes[indexKey][groupKey]
+	if len(group) == 0 {
+		return nil
+	}
+	siblings := make([]indexedSeries[T], 0, len(group))
+	for key, s := range group {
+		if key != fullKey {
+			siblings = append(siblings, s)
+		}
+	}
+	return siblings
+}
+
+// validateIndexValues ensures the arity of indexValues matches the configured indexLabels.
+func (c *VecSet[T]) validateIndexValues(indexValues []string) error {
+	if len(indexValues) != len(c.indexLabels) {
+		return arityError(ErrIndexArity, "index", c.indexLabels, len(indexValues))
+	}
+	return nil
+}
+
+// validateGroupValues ensures the arity of groupValues matches the configured groupLabels.
+func (c *VecSet[T]) validateGroupValues(groupValues []string) error {
+	if len(groupValues) != len(c.groupLabels) {
+		return arityError(ErrGroupArity, "group", c.groupLabels, len(groupValues))
+	}
+	return nil
+}
+
+// validateExtraValues ensures the arity of extraValues matches the configured extraLabels.
+func (c *VecSet[T]) validateExtraValues(extraValues []string) error {
+	if len(extraValues) != len(c.extraLabels) {
+		return arityError(ErrExtraArity, "extra", c.extraLabels, len(extraValues))
+	}
+	if c.enum != nil {
+		return c.enum.validate(extraValues)
+	}
+	return nil
+}
+
+// validateValues validates the arity of a full (index, group, extra) tuple.
+func (c *VecSet[T]) validateValues(indexValues, groupValues, extraValues []string) error {
+	if err := c.validateIndexValues(indexValues); err != nil {
+		return err
+	}
+	if err := c.validateGroupValues(groupValues); err != nil {
+		return err
+	}
+	return c.validateExtraValues(extraValues)
+}
+
+// SetErrorHandler replaces the handler invoked when a non-Try operation fails.
+// Passing nil restores the default PanicErrorHandler. Call this before the set is used concurrently.
+func (c *VecSet[T]) SetErrorHandler(handler ErrorHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errorHandler = handler
+}
+
+// handleError routes err through the configured ErrorHandler.
+func (c *VecSet[T]) handleError(err error) {
+	c.mu.RLock()
+	handler := c.errorHandler
+	c.mu.RUnlock()
+
+	if handler == nil {
+		handler = PanicErrorHandler
+	} else {
+		c.handled.Add(1)
+	}
+	handler(err)
+}
+
+// removeIndexLocked removes indexKey from the nested index and the index trie.
+// The caller must hold the write lock.
+func (c *VecSet[T]) removeIndexLocked(indexKey string) {
+	if _, ok := c.indexes[indexKey]; !ok {
+		return
+	}
+	delete(c.indexes, indexKey)
+	delete(c.indexTicks, indexKey)
+	c.indexTrie.remove(deserialize(indexKey))
+}
+
+// forgetLocked drops the bookkeeping kept for fullKey, whose label values are values, besides the nested index.
+// The caller must hold the write lock and remove fullKey from the nested index.
+func (c *VecSet[T]) forgetLocked(fullKey string, values []string) {
+	delete(c.expiries, fullKey)
+	delete(c.seriesTicks, fullKey)
+	for _, secondary := range c.secondary {
+		secondary.remove(values, fullKey)
+	}
+	c.series--
+}
+
+// cacheWithKeys records the series ref and its child metric m in the nested index, using the set's default TTL.
+func (c *VecSet[T]) cacheWithKeys(ref seriesRef, m T) {
+	c.cacheWithTTL(ref, m, c.ttl)
+}
+
+// cacheWithTTL records the series ref and its child metric m in the nested index and stamps it with ttl.
+func (c *VecSet[T]) cacheWithTTL(ref seriesRef, m T, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stampLocked(ref.indexKey, ref.groupKey, ref.fullKey, ttl)
+	c.touchLocked(ref.indexKey, ref.fullKey)
+	c.insertLocked(ref, m)
+}
+
+// insertLocked records the series ref and its child metric m in the nested index, replacing the child if ref is
+// already indexed, and stamps it with the current sweep generation. The label values of an indexed series are kept,
+// since they may be interned. The caller must hold the write lock.
+func (c *VecSet[T]) insertLocked(ref seriesRef, m T) {
+	indexSet, ok := c.indexes[ref.indexKey]
+	if !ok {
+		indexSet = make(map[string]map[string]indexedSeries[T])
+		c.indexes[ref.indexKey] = indexSet
+		c.indexTrie.insert(deserialize(ref.indexKey), ref.indexKey)
+	}
+	groupSet, ok := indexSet[ref.groupKey]
+	if !ok {
+		groupSet = make(map[string]indexedSeries[T])
+		indexSet[c.internKey(ref.groupKey)] = groupSet
+	}
+
+	// Always replace the child: a series deleted and re-created in the vector has a new one.
+	generation := c.generation.Load()
+	if s, exists := groupSet[ref.fullKey]; exists {
+		groupSet[ref.fullKey] = indexedSeries[T]{metric: m, values: s.values, generation: generation}
+		return
+	}
+	groupSet[ref.fullKey] = indexedSeries[T]{metric: m, values: ref.allValues, generation: generation}
+	for _, secondary := range c.secondary {
+		secondary.insert(ref)
+	}
+	c.series++
+}
+
+// uncacheLocked removes a single fullKey from (indexKey, groupKey), pruning the group and index if they become
+// empty, and returns the removed series. The caller must hold the write lock.
+func (c *VecSet[T]) uncacheLocked(indexKey, groupKey, fullKey string) (indexedSeries[T], bool) {
+	groupMap := c.indexes[indexKey]
+	groupSet := groupMap[groupKey]
+	s, ok := groupSet[fullKey]
+	if !ok {
+		delete(c.expiries, fullKey)
+		return s, false
+	}
+	delete(groupSet, fullKey)
+	c.forgetLocked(fullKey, s.values)
+	if len(groupSet) == 0 {
+		delete(groupMap, groupKey)
+		if len(groupMap) == 0 {
+			c.removeIndexLocked(indexKey)
+		}
+	}
+	return s, true
+}
+
+// DeleteByIndex removes all series whose index label-values tuple equals indexValues.
+// Returns the number of deleted series.
+func (c *VecSet[T]) DeleteByIndex(indexValues ...string) (deleted int) {
+	deleted, err := c.TryDeleteByIndex(indexValues...)
+	if err != nil {
+		c.handleError(err)
+	}
+	return deleted
+}
+
+// TryDeleteByIndex is like DeleteByIndex but returns an error instead of invoking the error handler.
+func (c *VecSet[T]) TryDeleteByIndex(indexValues ...string) (deleted int, err error) {
+	if err := c.validateIndexValues(indexValues); err != nil {
+		return 0, err
+	}
+
+	return c.deleteByIndexKey(indexValues, serialize(indexValues)), nil
+}
+
+// deleteByIndexKey prunes indexKey, the key of indexValues, from the index and removes all series that were cached
+// under it. The gauge sets hold the locks of the removed groups, so their Collect never observes the deletion
+// halfway.
+func (c *VecSet[T]) deleteByIndexKey(indexValues []string, indexKey string) (deleted int) {
+	if c.exclusive {
+		stripes := c.lockBelow(indexValues)
+		defer c.groupLocks.unlockStripes(stripes)
+	}
+	return c.deleteSeries(c.detachIndex(indexKey))
+}
+
+// DeleteByIndexPrefix removes all series whose index label-values tuple starts with prefix.
+// The prefix may contain between zero and len(indexLabels) values; an empty prefix removes every series.
+// Returns the number of deleted series.
+func (c *VecSet[T]) DeleteByIndexPrefix(prefix ...string) (deleted int) {
+	deleted, err := c.TryDeleteByIndexPrefix(prefix...)
+	if err != nil {
+		c.handleError(err)
+	}
+	return deleted
+}
+
+// TryDeleteByIndexPrefix is like DeleteByIndexPrefix but returns an error instead of invoking the error handler.
+func (c *VecSet[T]) TryDeleteByIndexPrefix(prefix ...string) (deleted int, err error) {
+	if len(prefix) > len(c.indexLabels) {
+		return 0, fmt.Errorf("%w: expected at most %d indexValues for labels %v, got %d",
+			ErrIndexArity, len(c.indexLabels), c.indexLabels, len(prefix))
+	}
+
+	return c.deleteByIndexPrefix(prefix), nil
+}
+
+// deleteByIndexPrefix removes all series whose index values start with prefix. Like deleteByIndexKey, the gauge sets
+// hold the locks of the removed groups.
+func (c *VecSet[T]) deleteByIndexPrefix(prefix []string) (deleted int) {
+	if c.exclusive {
+		stripes := c.lockBelow(prefix)
+		defer c.groupLocks.unlockStripes(stripes)
+	}
+	return c.deleteSeries(c.detachIndexPrefix(prefix))
+}
+
+// DeleteByGroup removes all series for the given (indexValues, groupValues) pair.
+// Returns the number of deleted series.
+func (c *VecSet[T]) DeleteByGroup(indexValues []string, groupValues ...string) (deleted int) {
+	deleted, err := c.TryDeleteByGroup(indexValues, groupValues...)
+	if err != nil {
+		c.handleError(err)
+	}
+	return deleted
+}
+
+// TryDeleteByGroup is like DeleteByGroup but returns an error instead of invoking the error handler.
+func (c *VecSet[T]) TryDeleteByGroup(indexValues []string, groupValues ...string) (deleted int, err error) {
+	if len(c.groupLabels) == 0 {
+		return 0, nil
+	}
+	if err := c.validateIndexValues(indexValues); err != nil {
+		return 0, err
+	}
+	if err := c.validateGroupValues(groupValues); err != nil {
+		return 0, err
+	}
+
+	return c.deleteByGroupKey(serialize(indexValues), serialize(groupValues)), nil
+}
+
+// deleteByGroupKey prunes (indexKey, groupKey) from the index and removes all series that were cached under it.
+func (c *VecSet[T]) deleteByGroupKey(indexKey, groupKey string) (deleted int) {
+	groupLock := c.groupLocks.stripe(indexKey, groupKey)
+	groupLock.Lock()
+	defer groupLock.Unlock()
+
+	return c.deleteSeries(c.detachGroup(indexKey, groupKey))
+}
+
+// deleteByFullKey removes the single series fullKey cached under (indexKey, groupKey), pruning the group and index if
+// they become empty.
+func (c *VecSet[T]) deleteByFullKey(indexKey, groupKey, fullKey string) (deleted int) {
+	groupLock := c.groupLocks.stripe(indexKey, groupKey)
+	groupLock.Lock()
+	defer groupLock.Unlock()
+
+	c.mu.Lock()
+	s, ok := c.uncacheLocked(indexKey, groupKey, fullKey)
+	c.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	return c.deleteSeries([][]string{s.values})
+}
@@ -0,0 +1,179 @@
+package gauge_vec_set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ensure counters get the same bulk deletion semantics as gauges
+func Test_CounterVecSet_IncAndDelete(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewCounterVecSet(
+		"testns",
+		"subsys",
+		"errors_total",
+		"help text",
+		[]string{"controller", "name"}, // index
+		[]string{"phase"},              // group
+		"reason",                       // extra
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Inc([]string{"ctrl", "a"}, []string{"reconcile"}, "timeout")
+	col.Add(2, []string{"ctrl", "a"}, []string{"reconcile"}, "timeout")
+	col.Inc([]string{"ctrl", "a"}, []string{"finalize"}, "conflict")
+	col.Inc([]string{"ctrl", "b"}, []string{"reconcile"}, "timeout")
+
+	want := `
+# HELP testns_subsys_errors_total help text
+# TYPE testns_subsys_errors_total counter
+testns_subsys_errors_total{controller="ctrl",name="a",phase="finalize",reason="conflict"} 1
+testns_subsys_errors_total{controller="ctrl",name="a",phase="reconcile",reason="timeout"} 3
+testns_subsys_errors_total{controller="ctrl",name="b",phase="reconcile",reason="timeout"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_errors_total"))
+
+	assert.Equal(t, 1, col.DeleteByGroup([]string{"ctrl", "a"}, "finalize"))
+	assert.Equal(t, 1, col.DeleteByIndex("ctrl", "a"))
+	assert.Equal(t, 1, col.DeleteByIndexPrefix("ctrl"))
+	assert.Empty(t, col.indexes)
+
+	assert.ErrorIs(t, col.TryAdd(-1, []string{"ctrl", "a"}, []string{"reconcile"}, "timeout"), ErrNegativeCounterIncrement)
+	assert.ErrorIs(t, col.TryInc([]string{"ctrl"}, []string{"reconcile"}, "timeout"), ErrIndexArity)
+	assert.Empty(t, col.indexes)
+}
+
+// Ensure WithLabelValues returns a usable child that is neither indexed nor exported if the error handler returns
+func Test_VecSet_WithLabelValues_ErrorHandler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	col := NewCounterVecSet("testns", "subsys", "dropped_total", "help text",
+		[]string{"controller", "name"}, // index
+		nil,                            // group
+		"reason",                       // extra
+	)
+	require.NoError(t, reg.Register(col))
+	var handled []error
+	col.SetErrorHandler(func(err error) { handled = append(handled, err) })
+
+	assert.NotPanics(t, func() {
+		col.WithLabelValues([]string{"ctrl", "a"}, nil).Inc()
+		col.WithLabelValues([]string{"ctrl"}, nil, "timeout").Add(2)
+	})
+	require.Len(t, handled, 2)
+	assert.ErrorIs(t, handled[0], ErrExtraArity)
+	assert.ErrorIs(t, handled[1], ErrIndexArity)
+	assert.Empty(t, col.indexes)
+	assert.Equal(t, 0, testutil.CollectAndCount(reg, "testns_subsys_dropped_total"))
+
+	histogram := NewHistogramVecSet(prometheus.HistogramOpts{Name: "dropped_seconds", Help: "help text"},
+		[]string{"name"}, // index
+		nil,              // group
+	)
+	histogram.SetErrorHandler(func(error) {})
+	assert.NotPanics(t, func() { histogram.WithLabelValues(nil, nil).Observe(1) })
+	assert.Equal(t, 0, testutil.CollectAndCount(histogram))
+}
+
+// Ensure histograms get the same bulk deletion semantics as gauges
+func Test_HistogramVecSet_ObserveAndDelete(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewHistogramVecSet(
+		prometheus.HistogramOpts{
+			Namespace: "testns",
+			Subsystem: "subsys",
+			Name:      "reconcile_seconds",
+			Help:      "help text",
+			Buckets:   []float64{1, 5},
+		},
+		[]string{"name"}, // index
+		nil,              // no group labels
+		"result",         // extra
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Observe(0.5, []string{"a"}, nil, "ok")
+	col.Observe(3, []string{"a"}, nil, "ok")
+	col.Observe(10, []string{"b"}, nil, "error")
+
+	assert.Equal(t, 1, col.DeleteByIndex("b"))
+
+	want := `
+# HELP testns_subsys_reconcile_seconds help text
+# TYPE testns_subsys_reconcile_seconds histogram
+testns_subsys_reconcile_seconds_bucket{name="a",result="ok",le="1"} 1
+testns_subsys_reconcile_seconds_bucket{name="a",result="ok",le="5"} 2
+testns_subsys_reconcile_seconds_bucket{name="a",result="ok",le="+Inf"} 2
+testns_subsys_reconcile_seconds_sum{name="a",result="ok"} 3.5
+testns_subsys_reconcile_seconds_count{name="a",result="ok"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_reconcile_seconds"))
+
+	assert.ErrorIs(t, col.TryObserve(1, []string{"a"}, nil), ErrExtraArity)
+}
+
+// Ensure summaries get the same bulk deletion semantics as gauges
+func Test_SummaryVecSet_ObserveAndDelete(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	col := NewSummaryVecSet(
+		prometheus.SummaryOpts{
+			Namespace: "testns",
+			Subsystem: "subsys",
+			Name:      "payload_bytes",
+			Help:      "help text",
+		},
+		[]string{"name"},  // index
+		[]string{"queue"}, // group
+	)
+	require.NoError(t, reg.Register(col))
+
+	col.Observe(10, []string{"a"}, []string{"q1"})
+	col.Observe(20, []string{"a"}, []string{"q2"})
+	col.Observe(30, []string{"b"}, []string{"q1"})
+
+	assert.Equal(t, 1, col.DeleteByGroup([]string{"a"}, "q2"))
+	assert.Equal(t, 1, col.DeleteByIndex("b"))
+
+	want := `
+# HELP testns_subsys_payload_bytes help text
+# TYPE testns_subsys_payload_bytes summary
+testns_subsys_payload_bytes_sum{name="a",queue="q1"} 10
+testns_subsys_payload_bytes_count{name="a",queue="q1"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "testns_subsys_payload_bytes"))
+}
+
+func Test_VecSet_WithLabelValues(t *testing.T) {
+	col := NewCounterVecSet("testns", "subsys", "raw_total", "help text", []string{"name"}, nil, "result")
+
+	col.WithLabelValues([]string{"a"}, nil, "ok").Add(5)
+	assert.Equal(t, float64(5), testutil.ToFloat64(col.WithLabelValues([]string{"a"}, nil, "ok")))
+	assert.Equal(t, 1, col.DeleteByIndex("a"))
+
+	_, err := col.TryWithLabelValues([]string{"a"}, []string{"unexpected"}, "ok")
+	assert.ErrorIs(t, err, ErrGroupArity)
+	assert.Panics(t, func() { col.WithLabelValues(nil, nil, "ok") })
+}
+
+// Ensure sets without exclusive group writes are scraped without taking the group locks
+func Test_VecSet_CollectWithoutGroupLocks(t *testing.T) {
+	col := NewCounterVecSet("testns", "subsys", "unlocked_total", "help text", []string{"name"}, []string{"phase"})
+	col.Inc([]string{"a"}, []string{"reconcile"})
+
+	for i := range col.groupLocks {
+		col.groupLocks[i].Lock()
+	}
+	defer func() {
+		for i := range col.groupLocks {
+			col.groupLocks[i].Unlock()
+		}
+	}()
+	assert.Equal(t, 1, testutil.CollectAndCount(col))
+}